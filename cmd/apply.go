@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyFile string
+
+	apply = &cobra.Command{
+		Use:   "apply",
+		Short: "Reconcile your local Conduit deployment with a conduit.yaml manifest",
+		Run:   runApply,
+	}
+)
+
+func init() {
+	deploy.AddCommand(apply)
+	//deploy apply
+	apply.PersistentFlags().StringVarP(&applyFile, "file", "f", "conduit.yaml", "manifest to apply")
+}
+
+func runApply(cmd *cobra.Command, args []string) {
+	if applyFile == "" {
+		PrintFatalError(NewApplyError(errors.New("use --file to set the manifest to apply")))
+	}
+	//resolve before changing to the project root
+	src, err := filepath.Abs(applyFile)
+	if err != nil {
+		PrintFatalError(NewApplyError(err))
+	}
+	manifest, err := conduit.LoadManifest(src)
+	if err != nil {
+		PrintFatalError(NewApplyError(err))
+	}
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewApplyError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewApplyError(err))
+	}
+	changes, err := con.Apply(ctx, manifest)
+	if err != nil {
+		PrintFatalError(NewApplyError(err))
+	}
+	if len(changes) == 0 {
+		PrintSuccess("already up to date")
+		return
+	}
+	PrintSuccess(fmt.Sprintf("applied %s\n%s", filepath.Base(src), strings.Join(changes, "\n")))
+}
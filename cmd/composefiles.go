@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	composeFilesCommand = &cobra.Command{
+		Use:   "compose-files",
+		Short: "Manage the compose files layered on top of docker-compose.yaml",
+		Run:   runDeploy,
+	}
+	composeFilesSet = &cobra.Command{
+		Use:   "set <file...>",
+		Short: "Record compose files in conduit.json so every later command layers them",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runComposeFilesSet,
+	}
+	composeFilesClear = &cobra.Command{
+		Use:   "clear",
+		Short: "Stop layering the compose files recorded in conduit.json",
+		Args:  cobra.NoArgs,
+		Run:   runComposeFilesClear,
+	}
+)
+
+func init() {
+	deploy.AddCommand(composeFilesCommand)
+	composeFilesCommand.AddCommand(composeFilesSet)
+	composeFilesCommand.AddCommand(composeFilesClear)
+}
+
+func runComposeFilesSet(cmd *cobra.Command, args []string) {
+	files := resolveComposeFiles(args)
+	setComposeFiles(files)
+	PrintSuccess(fmt.Sprintf("recorded %s in conduit.json", strings.Join(files, ", ")))
+}
+
+func runComposeFilesClear(cmd *cobra.Command, args []string) {
+	setComposeFiles([]string{})
+	PrintSuccess("removed the compose files from conduit.json")
+}
+
+func setComposeFiles(files []string) {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewComposeFileError(err))
+		}
+	}
+	if err := conduit.SetComposeFiles(files); err != nil {
+		PrintFatalError(NewComposeFileError(err))
+	}
+}
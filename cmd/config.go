@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configFormat         string
+	configServices       bool
+	configProfiles       bool
+	configImages         bool
+	configVolumes        bool
+	configResolveDigests bool
+
+	config = &cobra.Command{
+		Use:   "config",
+		Short: "Print the compose project of your local Conduit deployment as it will be run",
+		Run:   runConfig,
+	}
+)
+
+func init() {
+	deploy.AddCommand(config)
+	//deploy config
+	config.PersistentFlags().StringVar(&configFormat, "format", "yaml", "format of the project either yaml or json")
+	config.PersistentFlags().BoolVar(&configServices, "services", false, "list the enabled services")
+	config.PersistentFlags().BoolVar(&configProfiles, "profiles", false, "list the profiles the compose file defines")
+	config.PersistentFlags().BoolVar(&configImages, "images", false, "list the images of the enabled services")
+	config.PersistentFlags().BoolVar(&configVolumes, "volumes", false, "list the volumes")
+	config.PersistentFlags().BoolVar(&configResolveDigests, "resolve-image-digests", false, "pin the image tags to the digests the registries resolve them to (with --images or the whole project)")
+	config.MarkFlagsMutuallyExclusive("services", "profiles", "images", "volumes")
+	//the listings without images have nothing to pin
+	for _, flag := range []string{"services", "profiles", "volumes"} {
+		config.MarkFlagsMutuallyExclusive("resolve-image-digests", flag)
+	}
+}
+
+func runConfig(cmd *cobra.Command, args []string) {
+	if configFormat != "yaml" && configFormat != "json" {
+		PrintFatalError(NewConfigError(errors.New("use --format yaml or --format json")))
+	}
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewConfigError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewConfigError(err))
+	}
+	if configResolveDigests {
+		if err := con.ResolveImageDigests(ctx); err != nil {
+			PrintFatalError(NewConfigError(err))
+		}
+	}
+	switch {
+	case configServices:
+		for _, service := range con.Services() {
+			fmt.Println(service)
+		}
+	case configProfiles:
+		for _, profile := range con.Profiles() {
+			fmt.Println(profile)
+		}
+	case configImages:
+		printSortedPairs(con.Images())
+	case configVolumes:
+		printSortedPairs(con.Volumes())
+	default:
+		b, err := con.Config(ctx, configFormat)
+		if err != nil {
+			PrintFatalError(NewConfigError(err))
+		}
+		fmt.Print(string(b))
+	}
+}
+
+// Prints the keys and their values in two aligned columns sorted by key
+func printSortedPairs(pairs map[string]string) {
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", key, pairs[key])
+	}
+	w.Flush()
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupOut      string
+	backupDir      string
+	backupSchedule string
+	backupKeep     int
+	backupMaxAge   time.Duration
+	backupOnce     bool
+	backupDetach   bool
+	restoreForce   bool
+
+	db = &cobra.Command{
+		Use:   "db",
+		Short: "Back up and restore the database of your local Conduit deployment",
+		Run:   runDeploy,
+	}
+	dbBackup = &cobra.Command{
+		Use:   "backup",
+		Short: "Dump the database to a compressed archive",
+		Run:   runDbBackup,
+	}
+	dbRestore = &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore the database from an archive created with deploy db backup",
+		Args:  cobra.ExactArgs(1),
+		Run:   runDbRestore,
+	}
+)
+
+func init() {
+	deploy.AddCommand(db)
+	db.AddCommand(dbBackup)
+	db.AddCommand(dbRestore)
+	//deploy db backup
+	dbBackup.PersistentFlags().StringVar(&backupOut, "out", "", "path of the archive (defaults to <dir>/<project>-<database>-<timestamp>.tar.gz)")
+	dbBackup.PersistentFlags().StringVar(&backupDir, "dir", "", "directory the archives are written to (defaults to backups)")
+	dbBackup.PersistentFlags().StringVar(&backupSchedule, "schedule", "", "take a backup on a schedule eg: \"@every 6h\", @hourly, @daily or @weekly")
+	dbBackup.PersistentFlags().IntVar(&backupKeep, "keep", 0, "number of archives to keep, older ones are deleted (0 keeps all)")
+	dbBackup.PersistentFlags().DurationVar(&backupMaxAge, "max-age", 0, "delete archives older than this eg: 168h (0 keeps all)")
+	dbBackup.PersistentFlags().BoolVar(&backupOnce, "once", false, "take a single backup even if conduit.json has a schedule")
+	dbBackup.PersistentFlags().BoolVar(&backupDetach, "detach", false, "run the schedule in the background logging to <dir>/backup.log")
+	dbBackup.MarkFlagsMutuallyExclusive("out", "schedule")
+	dbBackup.MarkFlagsMutuallyExclusive("once", "schedule")
+	//deploy db restore
+	dbRestore.PersistentFlags().BoolVar(&restoreForce, "force", false, "restore an archive taken with a different conduit image tag")
+}
+
+func runDbBackup(cmd *cobra.Command, args []string) {
+	out := backupOut
+	if out != "" {
+		//resolved before changing to the project root
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			PrintFatalError(NewBackupError(err))
+		}
+		out = abs
+	}
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewBackupError(err))
+		}
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewBackupError(err))
+	}
+	if !con.HasDatabase() {
+		PrintFatalError(NewBackupError(fmt.Errorf("the project has no database")))
+	}
+	//the backup section of conduit.json fills in the flags that were not set
+	defaults := con.BackupConfig()
+	if !cmd.Flags().Changed("dir") {
+		backupDir = defaults.Dir
+	}
+	if !cmd.Flags().Changed("keep") {
+		backupKeep = defaults.Keep
+	}
+	if !cmd.Flags().Changed("max-age") && defaults.MaxAge != "" {
+		if backupMaxAge, err = time.ParseDuration(defaults.MaxAge); err != nil {
+			PrintFatalError(NewBackupError(fmt.Errorf("invalid backup.maxAge in conduit.json: %s", err)))
+		}
+	}
+	if !cmd.Flags().Changed("schedule") && !backupOnce && out == "" {
+		backupSchedule = defaults.Schedule
+	}
+	if backupDir == "" {
+		backupDir = "backups"
+	}
+	retention := conduit.BackupRetention{Keep: backupKeep, MaxAge: backupMaxAge}
+
+	if backupSchedule == "" {
+		if backupDetach {
+			PrintFatalError(NewBackupError(errors.New("--detach needs a schedule")))
+		}
+		if out == "" {
+			out = conduit.BackupPath(backupDir, con.ProjectName(), con.Database(), time.Now())
+		}
+		if _, err := con.BackupDatabase(ctx, out); err != nil {
+			PrintFatalError(NewBackupError(err))
+		}
+		if backupOut == "" {
+			if _, err := conduit.PruneBackups(backupDir, con.ProjectName(), con.Database(), retention, time.Now()); err != nil {
+				PrintFatalError(NewBackupError(err))
+			}
+		}
+		PrintSuccess(fmt.Sprintf("backed up %s to %s", con.Database(), out))
+		return
+	}
+
+	schedule, err := conduit.ParseSchedule(backupSchedule)
+	if err != nil {
+		PrintFatalError(NewBackupError(err))
+	}
+	if err := os.MkdirAll(backupDir, fs.ModePerm); err != nil {
+		PrintFatalError(NewBackupError(err))
+	}
+	if backupDetach {
+		pid, logFile, err := detachBackupScheduler(cmd, retention)
+		if err != nil {
+			PrintFatalError(NewBackupError(err))
+		}
+		PrintSuccess(fmt.Sprintf("backup scheduler running in the background (pid %d) logging to %s, its pid is kept in %s", pid, logFile, filepath.Join(backupDir, "backup.pid")))
+		return
+	}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	if err := con.RunScheduledBackups(ctx, schedule, backupDir, retention, logger); err != nil {
+		PrintFatalError(NewBackupError(err))
+	}
+}
+
+// Starts this binary again running the schedule in the foreground of a new session so the schedule, pruning
+// and archives come from the same code as without --detach, returns its pid and log file
+func detachBackupScheduler(cmd *cobra.Command, retention conduit.BackupRetention) (int, string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, "", err
+	}
+	logFile := filepath.Join(backupDir, "backup.log")
+	log, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, fs.ModePerm)
+	if err != nil {
+		return 0, "", err
+	}
+	defer log.Close()
+	args := []string{"deploy", "db", "backup",
+		"--schedule", backupSchedule,
+		"--dir", backupDir,
+		"--keep", strconv.Itoa(retention.Keep),
+		"--max-age", retention.MaxAge.String(),
+	}
+	if cmd.Flags().Changed("compose-file") {
+		args = append(args, "--compose-file", strings.Join(composeFiles, ","))
+	}
+	scheduler := exec.Command(executable, args...)
+	scheduler.Stdout = log
+	scheduler.Stderr = log
+	scheduler.SysProcAttr = detachedProcAttr()
+	if err := scheduler.Start(); err != nil {
+		return 0, "", err
+	}
+	pid := scheduler.Process.Pid
+	if err := os.WriteFile(filepath.Join(backupDir, "backup.pid"), []byte(strconv.Itoa(pid)), fs.ModePerm); err != nil {
+		return 0, "", err
+	}
+	return pid, logFile, scheduler.Process.Release()
+}
+
+func runDbRestore(cmd *cobra.Command, args []string) {
+	//resolved before changing to the project root
+	src, err := filepath.Abs(args[0])
+	if err != nil {
+		PrintFatalError(NewRestoreError(err))
+	}
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewRestoreError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewRestoreError(err))
+	}
+	metadata, err := con.RestoreDatabaseBackup(ctx, src, restoreForce)
+	if err != nil {
+		PrintFatalError(NewRestoreError(err))
+	}
+	PrintSuccess(fmt.Sprintf("restored %s backup taken %s", metadata.Database, metadata.CreatedAt.Local().Format(time.RFC1123)))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"os/signal"
@@ -11,6 +12,8 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
+	"github.com/isolateminds/go-conduit-cli/internal/fetcher"
 	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
 	"github.com/spf13/cobra"
 	"k8s.io/utils/strings/slices"
@@ -21,6 +24,8 @@ var (
 	lokiCfg []byte
 	//go:embed embed/prometheus.cfg.yml
 	prometheusCfg []byte
+	//go:embed embed/project.gitignore
+	projectGitignore []byte
 )
 
 var (
@@ -31,6 +36,12 @@ var (
 	uiImageTag    string
 	mountDatabase bool
 	detach        bool
+	assumeYes     bool
+	templateDir   string
+	templateURL   string
+	templateSums  string
+	manifestFile  string
+	composeFiles  []string
 
 	deploy = &cobra.Command{
 		Use:              "deploy",
@@ -73,6 +84,8 @@ func init() {
 	deploy.AddCommand(recreate)
 	deploy.AddCommand(rm)
 	//Flags
+	//Deploy, -f is the manifest of setup and apply so the layered compose files have no shorthand
+	deploy.PersistentFlags().StringSliceVar(&composeFiles, "compose-file", []string{}, "compose files to layer on top of docker-compose.yaml in order for this command only, use deploy compose-files set to record them")
 	//Deploy setup
 	setup.PersistentFlags().StringSliceVar(&profiles, "profiles", []string{}, "profiles to enable")
 	setup.PersistentFlags().StringVar(&projectName, "project-name", "conduit", "set the project name")
@@ -80,6 +93,15 @@ func init() {
 	setup.PersistentFlags().StringVar(&uiImageTag, "ui-image-tag", "latest", "set the conduit ui image tag to use")
 	setup.PersistentFlags().BoolVar(&detach, "detach", false, "run containers in the background")
 	setup.PersistentFlags().BoolVar(&mountDatabase, "mount-database", false, "bind mount the database to the project directory")
+	setup.PersistentFlags().BoolVar(&assumeYes, "yes", false, "do not ask for missing options, use the flags and their defaults")
+	setup.PersistentFlags().StringVar(&templateDir, "template-dir", "", "read the docker compose and .env templates from a local directory instead of the embedded ones")
+	setup.PersistentFlags().StringVar(&templateURL, "template-url", "", "fetch the docker compose and .env templates from a base url instead of the embedded ones")
+	setup.PersistentFlags().StringVar(&templateSums, "template-checksums", "", "verify the templates fetched with --template-url against a sha256sum manifest file")
+	setup.PersistentFlags().StringVarP(&manifestFile, "file", "f", "", "declare the project with a conduit.yaml manifest instead of flags")
+	setup.MarkFlagsMutuallyExclusive("template-dir", "template-url")
+	for _, flag := range []string{"profiles", "project-name", "image-tag", "ui-image-tag", "mount-database"} {
+		setup.MarkFlagsMutuallyExclusive("file", flag)
+	}
 
 	//deploy start
 	start.PersistentFlags().BoolVar(&detach, "detach", false, "run containers in the background")
@@ -108,6 +130,37 @@ func runPreRun(cmd *cobra.Command, args []string) {
 			return strings.TrimSpace(s) != ""
 		})
 	}
+	if cmd.Flags().Changed("compose-file") {
+		//setup creates the project and compose-files records the files, neither loads a project to layer them on
+		if cmd == setup || cmd.Parent() == composeFilesCommand {
+			PrintFatalError(NewComposeFileError(fmt.Errorf("--compose-file can not be used with %s", cmd.CommandPath())))
+		}
+		composeFiles = resolveComposeFiles(composeFiles)
+	}
+}
+
+// Returns the options layering the --compose-file files instead of the recorded ones when the flag is set
+func composeFileOptions(cmd *cobra.Command) []composeopt.SetComposerOptions {
+	if !cmd.Flags().Changed("compose-file") {
+		return nil
+	}
+	return []composeopt.SetComposerOptions{composeopt.WithComposeFiles(composeFiles...)}
+}
+
+// Resolves the compose files before changing to the project root, empty ones are dropped so --compose-file "" layers none
+func resolveComposeFiles(files []string) []string {
+	resolved := []string{}
+	for _, file := range files {
+		if strings.TrimSpace(file) == "" {
+			continue
+		}
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			PrintFatalError(NewComposeFileError(err))
+		}
+		resolved = append(resolved, abs)
+	}
+	return resolved
 }
 func runRm(cmd *cobra.Command, args []string) {
 	if !IsInProjectDirectory() {
@@ -116,7 +169,7 @@ func runRm(cmd *cobra.Command, args []string) {
 		}
 	}
 	ctx := context.Background()
-	con, err := conduit.NewConduitFromProject(ctx, detach, []string{})
+	con, err := conduit.NewConduitFromProject(ctx, detach, []string{}, composeFileOptions(cmd)...)
 	if err != nil {
 		PrintFatalError(NewRemoveError(err))
 	}
@@ -132,7 +185,7 @@ func runStop(cmd *cobra.Command, args []string) {
 		}
 	}
 	ctx := context.Background()
-	con, err := conduit.NewConduitFromProject(ctx, detach, []string{})
+	con, err := conduit.NewConduitFromProject(ctx, detach, []string{}, composeFileOptions(cmd)...)
 	if err != nil {
 		PrintFatalError(NewStopError(err))
 	}
@@ -148,10 +201,11 @@ func runStart(cmd *cobra.Command, args []string) {
 		}
 	}
 	ctx := context.Background()
-	con, err := conduit.NewConduitFromProject(ctx, detach, profiles)
+	con, err := conduit.NewConduitFromProject(ctx, detach, profiles, composeFileOptions(cmd)...)
 	if err != nil {
 		PrintFatalError(NewStartError(err))
 	}
+	printRequiredProfiles(con.RequiredProfiles())
 	err = con.WriteConduitJsonFile()
 	if err != nil {
 		PrintFatalError(NewStartError(err))
@@ -174,6 +228,57 @@ func runSetup(cmd *cobra.Command, args []string) {
 	if IsInProjectDirectory() {
 		PrintFatalError(NewSetupError(errors.New("already in project directory")))
 	}
+	templateSource := composeopt.EmbeddedTemplateSource()
+	switch {
+	case templateDir != "":
+		abs, err := filepath.Abs(templateDir)
+		if err != nil {
+			PrintFatalError(NewSetupError(err))
+		}
+		templateSource = composeopt.DirTemplateSource(abs)
+	case templateURL != "":
+		fetcherOptions := []fetcher.SetFetcherOptions{}
+		if templateSums != "" {
+			manifest, err := fetcher.ReadManifest(templateSums)
+			if err != nil {
+				PrintFatalError(NewSetupError(err))
+			}
+			fetcherOptions = append(fetcherOptions, fetcher.WithManifest(manifest))
+		}
+		templateSource = composeopt.URLTemplateSource(templateURL, fetcherOptions...)
+	}
+	if templateSums != "" && templateURL == "" {
+		PrintFatalError(NewSetupError(errors.New("--template-checksums can only be used with --template-url")))
+	}
+	var manifest *conduit.Manifest
+	if manifestFile != "" {
+		m, err := conduit.LoadManifest(manifestFile)
+		if err != nil {
+			PrintFatalError(NewSetupError(err))
+		}
+		_, modules, err := conduit.TemplateProfiles(templateSource)
+		if err != nil {
+			PrintFatalError(NewSetupError(err))
+		}
+		if err := m.ValidateModules(modules); err != nil {
+			PrintFatalError(NewSetupError(err))
+		}
+		manifest = m
+		projectName, profiles = m.ProjectName, m.Profiles()
+	}
+	//ask for the missing options unless told not to or there is no terminal to ask on
+	if manifest == nil && !assumeYes && isInteractive() && (databaseProfile(profiles) == "" || !cmd.Flags().Changed("profiles")) {
+		confirmed, err := runSetupWizard(cmd, templateSource)
+		if err != nil {
+			PrintFatalError(NewSetupError(err))
+		}
+		if !confirmed {
+			PrintFatalError(NewSetupError(errors.New("setup canceled")))
+		}
+	}
+	if databaseProfile(profiles) == "" {
+		PrintFatalError(NewSetupError(errors.New("a database profile is required use --profiles mongodb or --profiles postgres")))
+	}
 	if err := os.Mkdir(projectName, fs.ModePerm); err != nil {
 		PrintFatalError(NewSetupError(err))
 	}
@@ -199,13 +304,21 @@ func runSetup(cmd *cobra.Command, args []string) {
 		deletePDir()
 		PrintFatalError(NewSetupError(err))
 	}
+	if err := os.WriteFile(".gitignore", projectGitignore, fs.ModePerm); err != nil {
+		deletePDir()
+		PrintFatalError(NewSetupError(err))
+	}
 	options := &conduit.BootstrapperOptions{
-		ProjectName:   projectName,
-		Detached:      detach,
-		Profiles:      profiles,
-		ImageTag:      imageTag,
-		UIImageTag:    uiImageTag,
-		MountDatabase: mountDatabase,
+		ProjectName:    projectName,
+		Detached:       detach,
+		Profiles:       profiles,
+		ImageTag:       imageTag,
+		UIImageTag:     uiImageTag,
+		MountDatabase:  mountDatabase,
+		TemplateSource: templateSource,
+	}
+	if manifest != nil {
+		options = manifest.BootstrapperOptions(detach, templateSource)
 	}
 	ctx := context.Background()
 	con, err := conduit.NewConduitBootstrapper(ctx, options)
@@ -214,6 +327,7 @@ func runSetup(cmd *cobra.Command, args []string) {
 		PrintFatalError(NewSetupError(err))
 
 	}
+	printRequiredProfiles(con.RequiredProfiles())
 	if err := con.WriteComposeFile(); err != nil {
 		deletePDir()
 		PrintFatalError(NewSetupError(err))
@@ -246,7 +360,7 @@ func runRecreate(cmd *cobra.Command, args []string) {
 	}
 	ctx := context.Background()
 
-	con, err := conduit.NewConduitFromProject(ctx, detach, profiles)
+	con, err := conduit.NewConduitFromProject(ctx, detach, profiles, composeFileOptions(cmd)...)
 	if err != nil {
 		PrintFatalError(NewRecreateError(err))
 	}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package cmd
+
+import "syscall"
+
+// Starts the process in a new session so it keeps running after the terminal is closed
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
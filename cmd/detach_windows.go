@@ -0,0 +1,15 @@
+//go:build windows
+
+package cmd
+
+import "syscall"
+
+const (
+	createNewProcessGroup = 0x00000200
+	detachedProcess       = 0x00000008
+)
+
+// Starts the process without a console so it keeps running after the terminal is closed
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	env = &cobra.Command{
+		Use:   "env",
+		Short: "Inspect the environment of your local Conduit deployment",
+		Run:   runDeploy,
+	}
+	envSources = &cobra.Command{
+		Use:   "sources <KEY>",
+		Short: "Show which env layer sets a variable and the value each layer gives it",
+		Args:  cobra.ExactArgs(1),
+		Run:   runEnvSources,
+	}
+)
+
+func init() {
+	deploy.AddCommand(env)
+	env.AddCommand(envSources)
+}
+
+func runEnvSources(cmd *cobra.Command, args []string) {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewEnvError(err))
+		}
+	}
+	con, err := conduit.NewConduitFromProject(context.Background(), true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewEnvError(err))
+	}
+	key := args[0]
+	values := con.EnvValues(key)
+	if len(values) == 0 {
+		PrintFatalError(NewEnvError(fmt.Errorf("%s is not set by .env, .env.local, .env.$%s or the process environment", key, conduit.EnvironmentVariable)))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tVALUE\tUSED")
+	for _, value := range values {
+		used := ""
+		if value.Active {
+			used = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", value.Source, value.Value, used)
+	}
+	w.Flush()
+}
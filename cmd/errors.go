@@ -44,6 +44,150 @@ func (e recreateError) Error() string {
 	return fmt.Sprintf("RecreateError: %s", e.message)
 }
 
+type statusError struct {
+	message string
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("StatusError: %s", e.message)
+}
+
+type logsError struct {
+	message string
+}
+
+func (e logsError) Error() string {
+	return fmt.Sprintf("LogsError: %s", e.message)
+}
+
+type statsError struct {
+	message string
+}
+
+func (e statsError) Error() string {
+	return fmt.Sprintf("StatsError: %s", e.message)
+}
+
+type metricsError struct {
+	message string
+}
+
+func (e metricsError) Error() string {
+	return fmt.Sprintf("MetricsError: %s", e.message)
+}
+
+type execError struct {
+	message string
+}
+
+func (e execError) Error() string {
+	return fmt.Sprintf("ExecError: %s", e.message)
+}
+
+type runError struct {
+	message string
+}
+
+func (e runError) Error() string {
+	return fmt.Sprintf("RunError: %s", e.message)
+}
+
+type updateError struct {
+	message string
+}
+
+func (e updateError) Error() string {
+	return fmt.Sprintf("UpdateError: %s", e.message)
+}
+
+type rollbackError struct {
+	message string
+}
+
+func (e rollbackError) Error() string {
+	return fmt.Sprintf("RollbackError: %s", e.message)
+}
+
+type storageError struct {
+	message string
+}
+
+func (e storageError) Error() string {
+	return fmt.Sprintf("StorageError: %s", e.message)
+}
+
+type backupError struct {
+	message string
+}
+
+func (e backupError) Error() string {
+	return fmt.Sprintf("BackupError: %s", e.message)
+}
+
+type restoreError struct {
+	message string
+}
+
+func (e restoreError) Error() string {
+	return fmt.Sprintf("RestoreError: %s", e.message)
+}
+
+type exportError struct {
+	message string
+}
+
+func (e exportError) Error() string {
+	return fmt.Sprintf("ExportError: %s", e.message)
+}
+
+type importError struct {
+	message string
+}
+
+func (e importError) Error() string {
+	return fmt.Sprintf("ImportError: %s", e.message)
+}
+
+type applyError struct {
+	message string
+}
+
+func (e applyError) Error() string {
+	return fmt.Sprintf("ApplyError: %s", e.message)
+}
+
+type modulesError struct {
+	message string
+}
+
+func (e modulesError) Error() string {
+	return fmt.Sprintf("ModulesError: %s", e.message)
+}
+
+type configError struct {
+	message string
+}
+
+func (e configError) Error() string {
+	return fmt.Sprintf("ConfigError: %s", e.message)
+}
+
+type composeFileError struct {
+	message string
+}
+
+func (e composeFileError) Error() string {
+	return fmt.Sprintf("ComposeFileError: %s", e.message)
+}
+
+type envError struct {
+	message string
+}
+
+func (e envError) Error() string {
+	return fmt.Sprintf("EnvError: %s", e.message)
+}
+
 func NewSetupError(err error) error {
 	return &setupError{message: err.Error()}
 }
@@ -62,3 +206,68 @@ func NewStopError(err error) error {
 func NewRecreateError(err error) error {
 	return &recreateError{message: err.Error()}
 }
+func NewStatusError(err error) error {
+	return &statusError{message: err.Error()}
+}
+func NewLogsError(err error) error {
+	return &logsError{message: err.Error()}
+}
+func NewStatsError(err error) error {
+	return &statsError{message: err.Error()}
+}
+func NewMetricsError(err error) error {
+	return &metricsError{message: err.Error()}
+}
+func NewExecError(err error) error {
+	return &execError{message: err.Error()}
+}
+func NewRunError(err error) error {
+	return &runError{message: err.Error()}
+}
+func NewUpdateError(err error) error {
+	return &updateError{message: err.Error()}
+}
+
+func NewRollbackError(err error) error {
+	return &rollbackError{message: err.Error()}
+}
+
+func NewStorageError(err error) error {
+	return &storageError{message: err.Error()}
+}
+
+func NewBackupError(err error) error {
+	return &backupError{message: err.Error()}
+}
+
+func NewRestoreError(err error) error {
+	return &restoreError{message: err.Error()}
+}
+
+func NewExportError(err error) error {
+	return &exportError{message: err.Error()}
+}
+
+func NewImportError(err error) error {
+	return &importError{message: err.Error()}
+}
+
+func NewApplyError(err error) error {
+	return &applyError{message: err.Error()}
+}
+
+func NewModulesError(err error) error {
+	return &modulesError{message: err.Error()}
+}
+
+func NewConfigError(err error) error {
+	return &configError{message: err.Error()}
+}
+
+func NewComposeFileError(err error) error {
+	return &composeFileError{message: err.Error()}
+}
+
+func NewEnvError(err error) error {
+	return &envError{message: err.Error()}
+}
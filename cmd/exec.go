@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execUser    string
+	execWorkdir string
+	execEnv     []string
+	noTTY       bool
+
+	execCmd = &cobra.Command{
+		Use:   "exec <service> [cmd...]",
+		Short: "Execute a command in a running service container",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runExec,
+	}
+)
+
+func init() {
+	deploy.AddCommand(execCmd)
+	//everything after the service belongs to the command eg: exec core ls -la
+	execCmd.Flags().SetInterspersed(false)
+	//deploy exec
+	execCmd.PersistentFlags().StringVar(&execUser, "user", "", "run the command as this user")
+	execCmd.PersistentFlags().StringVar(&execWorkdir, "workdir", "", "path to the working directory for the command")
+	execCmd.PersistentFlags().StringArrayVar(&execEnv, "env", []string{}, "set environment variables eg: --env KEY=VALUE")
+	execCmd.PersistentFlags().BoolVar(&noTTY, "no-tty", false, "disable pseudo-TTY allocation (by default a TTY is allocated when stdin is a terminal)")
+}
+
+func runExec(cmd *cobra.Command, args []string) {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewExecError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewExecError(err))
+	}
+	command := args[1:]
+	if len(command) == 0 {
+		command = []string{"sh"}
+	}
+	code, err := con.Exec(ctx, args[0], &conduit.ExecOptions{
+		Cmd:         command,
+		User:        execUser,
+		WorkingDir:  execWorkdir,
+		Env:         execEnv,
+		TTY:         !noTTY && term.IsTerminal(os.Stdin.Fd()),
+		Interactive: true,
+	})
+	if err != nil {
+		PrintFatalError(NewExecError(err))
+	}
+	os.Exit(code)
+}
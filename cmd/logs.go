@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	follow     bool
+	tail       string
+	since      string
+	until      string
+	timestamps bool
+	noColor    bool
+
+	logs = &cobra.Command{
+		Use:   "logs [services...]",
+		Short: "View the logs of your local Conduit deployment",
+		Run:   runLogs,
+	}
+)
+
+func init() {
+	deploy.AddCommand(logs)
+	//deploy logs
+	logs.PersistentFlags().BoolVar(&follow, "follow", false, "follow log output")
+	logs.PersistentFlags().StringVar(&tail, "tail", "all", "number of lines to show from the end of the logs")
+	logs.PersistentFlags().StringVar(&since, "since", "", "show logs since timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)")
+	logs.PersistentFlags().StringVar(&until, "until", "", "show logs before timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m for 42 minutes)")
+	logs.PersistentFlags().BoolVar(&timestamps, "timestamps", false, "show timestamps")
+	logs.PersistentFlags().BoolVar(&noColor, "no-color", false, "produce monochrome output")
+}
+
+func runLogs(cmd *cobra.Command, args []string) {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewLogsError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, false, []string{}, append(composeFileOptions(cmd), composeopt.WithComposeLogConsumer(ctx, !noColor))...)
+	if err != nil {
+		PrintFatalError(NewLogsError(err))
+	}
+	err = con.Logs(ctx, types.LogOptions{
+		Services:   args,
+		Tail:       tail,
+		Since:      since,
+		Until:      until,
+		Follow:     follow,
+		Timestamps: timestamps,
+	})
+	if err != nil {
+		PrintFatalError(NewLogsError(err))
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/internal/metrics"
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+var (
+	metricsAddress  string
+	addScrapeTarget bool
+
+	metricsCmd = &cobra.Command{
+		Use:   "metrics",
+		Short: "Export your local Conduit deployment container metrics",
+		Run:   runDeploy,
+	}
+	metricsServe = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the project container stats on a prometheus /metrics endpoint",
+		Run:   runMetricsServe,
+	}
+)
+
+func init() {
+	deploy.AddCommand(metricsCmd)
+	metricsCmd.AddCommand(metricsServe)
+	//deploy metrics serve
+	metricsServe.PersistentFlags().StringVar(&metricsAddress, "address", ":9101", "address the /metrics endpoint listens on")
+	metricsServe.PersistentFlags().BoolVar(&addScrapeTarget, "add-scrape-target", false, "add the endpoint as a scrape target to the project prometheus.cfg.yml")
+}
+
+func runMetricsServe(cmd *cobra.Command, args []string) {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewMetricsError(err))
+		}
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewMetricsError(err))
+	}
+	_, port, err := net.SplitHostPort(metricsAddress)
+	if err != nil {
+		PrintFatalError(NewMetricsError(err))
+	}
+	if addScrapeTarget {
+		added, err := metrics.AddScrapeTarget("prometheus.cfg.yml", "goconduit", fmt.Sprintf("host.docker.internal:%s", port))
+		if err != nil {
+			PrintFatalError(NewMetricsError(err))
+		}
+		if added {
+			PrintSuccess("added scrape target to prometheus.cfg.yml\nTry: goconduit deploy recreate")
+		}
+	}
+
+	exporter := metrics.NewExporter(con.ProjectName())
+	go func() {
+		//refresh the containers periodically so newly started ones are picked up
+		for ctx.Err() == nil {
+			refreshCtx, stop := context.WithTimeout(ctx, 30*time.Second)
+			services := map[string]string{}
+			statuses, err := con.Status(refreshCtx)
+			if err != nil {
+				fmt.Println(chalk.Yellow.Color(NewMetricsError(err).Error()))
+			}
+			for _, s := range statuses {
+				services[s.Container] = s.Service
+			}
+			samples, errs := con.StreamStats(refreshCtx)
+			exporter.Consume(samples, services)
+			for err := range errs {
+				fmt.Println(chalk.Yellow.Color(NewMetricsError(err).Error()))
+			}
+			<-refreshCtx.Done()
+			stop()
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	server := &http.Server{Addr: metricsAddress, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	PrintSuccess(fmt.Sprintf("serving metrics on http://localhost:%s/metrics", port))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		PrintFatalError(NewMetricsError(err))
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	modules = &cobra.Command{
+		Use:   "modules",
+		Short: "Manage the Conduit modules of your local Conduit deployment",
+		Run:   runDeploy,
+	}
+	modulesEnable = &cobra.Command{
+		Use:   "enable <name...>",
+		Short: "Enable Conduit modules and start their containers",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runModulesEnable,
+	}
+	modulesDisable = &cobra.Command{
+		Use:   "disable <name...>",
+		Short: "Disable Conduit modules and remove their containers",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runModulesDisable,
+	}
+)
+
+func init() {
+	deploy.AddCommand(modules)
+	modules.AddCommand(modulesEnable)
+	modules.AddCommand(modulesDisable)
+}
+
+func runModulesEnable(cmd *cobra.Command, args []string) {
+	con := conduitForModules(cmd)
+	active, required, err := con.EnableModules(context.Background(), args)
+	if err != nil {
+		PrintFatalError(NewModulesError(err))
+	}
+	printRequiredProfiles(required)
+	printActiveModules(active)
+}
+
+func runModulesDisable(cmd *cobra.Command, args []string) {
+	con := conduitForModules(cmd)
+	active, err := con.DisableModules(context.Background(), args)
+	if err != nil {
+		PrintFatalError(NewModulesError(err))
+	}
+	printActiveModules(active)
+}
+
+func conduitForModules(cmd *cobra.Command) *conduit.Conduit {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewModulesError(err))
+		}
+	}
+	con, err := conduit.NewConduitFromProject(context.Background(), true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewModulesError(err))
+	}
+	return con
+}
+
+func printActiveModules(active []string) {
+	if len(active) == 0 {
+		PrintSuccess("no modules are active")
+		return
+	}
+	PrintSuccess(fmt.Sprintf("active modules: %s", strings.Join(active, ", ")))
+}
+
+// Tells which profiles were enabled because another profile depends on them
+func printRequiredProfiles(required map[string][]string) {
+	profiles := make([]string, 0, len(required))
+	for profile := range required {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	for _, profile := range profiles {
+		PrintNotice(fmt.Sprintf("enabled %s because %s requires it", profile, strings.Join(required[profile], ", ")))
+	}
+}
@@ -15,3 +15,8 @@ func PrintFatalError(err error) {
 func PrintSuccess(msg string) {
 	fmt.Println(chalk.Green.Color(msg))
 }
+
+// Prints something the user should know about that is not an error
+func PrintNotice(msg string) {
+	fmt.Println(chalk.Yellow.Color(msg))
+}
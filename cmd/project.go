@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importProjectName string
+
+	project = &cobra.Command{
+		Use:   "project",
+		Short: "Move a local Conduit deployment between machines",
+		Run:   runDeploy,
+	}
+	projectExport = &cobra.Command{
+		Use:   "export <archive.tar.gz>",
+		Short: "Bundle the project files and volumes into an archive",
+		Args:  cobra.ExactArgs(1),
+		Run:   runProjectExport,
+	}
+	projectImport = &cobra.Command{
+		Use:   "import <archive.tar.gz>",
+		Short: "Recreate a project from an archive created with project export",
+		Args:  cobra.ExactArgs(1),
+		Run:   runProjectImport,
+	}
+)
+
+func init() {
+	root.AddCommand(project)
+	project.AddCommand(projectExport)
+	project.AddCommand(projectImport)
+	//project import
+	projectImport.PersistentFlags().StringVar(&importProjectName, "project-name", "", "set the project name (defaults to the exported name or the next free name if it is taken)")
+}
+
+func runProjectExport(cmd *cobra.Command, args []string) {
+	//resolved before changing to the project root
+	dst, err := filepath.Abs(args[0])
+	if err != nil {
+		PrintFatalError(NewExportError(err))
+	}
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewExportError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewExportError(err))
+	}
+	if err := con.Export(ctx, dst); err != nil {
+		PrintFatalError(NewExportError(err))
+	}
+	PrintSuccess(fmt.Sprintf("exported %s to %s", con.ProjectName(), dst))
+}
+
+func runProjectImport(cmd *cobra.Command, args []string) {
+	if IsInProjectDirectory() {
+		PrintFatalError(NewImportError(errors.New("already in project directory")))
+	}
+	src, err := filepath.Abs(args[0])
+	if err != nil {
+		PrintFatalError(NewImportError(err))
+	}
+	manifest, err := conduit.ReadProjectArchiveManifest(src)
+	if err != nil {
+		PrintFatalError(NewImportError(err))
+	}
+	ctx := context.Background()
+	name := importProjectName
+	if name == "" {
+		name, err = conduit.AvailableProjectName(ctx, manifest.ProjectName, manifest)
+		if err != nil {
+			PrintFatalError(NewImportError(err))
+		}
+	}
+	if err := os.Mkdir(name, fs.ModePerm); err != nil {
+		PrintFatalError(NewImportError(err))
+	}
+	if err := os.Chdir(name); err != nil {
+		PrintFatalError(NewImportError(err))
+	}
+	pDir, err := os.Getwd()
+	if err != nil {
+		PrintFatalError(NewImportError(err))
+	}
+	deletePDir := func() { os.RemoveAll(pDir) }
+	sigCtx, cancelSigKill := context.WithCancel(context.Background())
+	handleSIGTERM(sigCtx, deletePDir)
+
+	if _, err := conduit.ImportProject(ctx, src, name); err != nil {
+		deletePDir()
+		PrintFatalError(NewImportError(err))
+	}
+	cancelSigKill()
+	PrintSuccess(fmt.Sprintf("imported %s into %s\nTry: goconduit deploy start", manifest.ProjectName, pDir))
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollback = &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll back your local Conduit deployment to the state before the last update",
+		Run:   runRollback,
+	}
+)
+
+func init() {
+	deploy.AddCommand(rollback)
+}
+
+func runRollback(cmd *cobra.Command, args []string) {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewRollbackError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewRollbackError(err))
+	}
+	rb := con.GetRollback()
+	if rb == nil {
+		PrintFatalError(NewRollbackError(fmt.Errorf("there is no update to roll back")))
+	}
+	if _, err := con.Rollback(ctx); err != nil {
+		PrintFatalError(NewRollbackError(err))
+	}
+	PrintSuccess(fmt.Sprintf("rolled back to %s", rb.Version))
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+)
+
+var (
+	autoRemove   bool
+	entrypoint   string
+	runEnv       []string
+	servicePorts bool
+	useAliases   bool
+
+	run = &cobra.Command{
+		Use:   "run <service> [cmd...]",
+		Short: "Run a one-off command in a new container of a service",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runRun,
+	}
+)
+
+func init() {
+	deploy.AddCommand(run)
+	//everything after the service belongs to the command eg: run database npm run migrate
+	run.Flags().SetInterspersed(false)
+	//deploy run
+	run.PersistentFlags().BoolVar(&autoRemove, "rm", false, "automatically remove the container when it exits")
+	run.PersistentFlags().StringVar(&entrypoint, "entrypoint", "", "override the entrypoint of the image (split on whitespace)")
+	run.PersistentFlags().StringArrayVarP(&runEnv, "env", "e", []string{}, "set environment variables eg: -e KEY=VALUE")
+	run.PersistentFlags().BoolVar(&servicePorts, "service-ports", false, "publish the ports of the service (fails while the service is running)")
+	run.PersistentFlags().BoolVar(&useAliases, "use-aliases", false, "register the service aliases so other services reach the container by them")
+	run.PersistentFlags().BoolVar(&noTTY, "no-tty", false, "disable pseudo-TTY allocation (by default a TTY is allocated when stdin is a terminal)")
+}
+
+func runRun(cmd *cobra.Command, args []string) {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewRunError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewRunError(err))
+	}
+	var entrypointOverride []string
+	if entrypoint != "" {
+		entrypointOverride = strings.Fields(entrypoint)
+	}
+	code, err := con.Run(ctx, types.RunOptions{
+		Service:      args[0],
+		Command:      args[1:],
+		Entrypoint:   entrypointOverride,
+		Environment:  runEnv,
+		AutoRemove:   autoRemove,
+		Tty:          !noTTY && term.IsTerminal(os.Stdin.Fd()),
+		Interactive:  true,
+		ServicePorts: servicePorts,
+		UseAliases:   useAliases,
+	})
+	if err != nil {
+		PrintFatalError(NewRunError(err))
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/internal/docker"
+	"github.com/isolateminds/go-conduit-cli/internal/docker/response"
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+	"k8s.io/utils/strings/slices"
+)
+
+// How long --no-stream waits for the second sample of every container
+const noStreamTimeout = 5 * time.Second
+
+var (
+	noStream    bool
+	statsFormat string
+
+	stats = &cobra.Command{
+		Use:   "stats",
+		Short: "Display a live stream of your local Conduit deployment containers resource usage",
+		Run:   runStats,
+	}
+)
+
+func init() {
+	deploy.AddCommand(stats)
+	//deploy stats
+	stats.PersistentFlags().BoolVar(&noStream, "no-stream", false, "disable streaming stats and only pull the first result")
+	stats.PersistentFlags().StringVar(&statsFormat, "format", "table", "output format either table, json or csv")
+}
+
+func runStats(cmd *cobra.Command, args []string) {
+	if !slices.Contains([]string{"table", "json", "csv"}, statsFormat) {
+		PrintFatalError(NewStatsError(fmt.Errorf("invalid format %s use table, json or csv", statsFormat)))
+	}
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewStatsError(err))
+		}
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewStatsError(err))
+	}
+	table := &statsTable{stats: map[string]response.FormatedContainerStats{}}
+
+	if noStream {
+		running, err := con.RunningContainers(ctx)
+		if err != nil {
+			PrintFatalError(NewStatsError(err))
+		}
+		//the first sample of each container has no previous cpu reading so wait for the second one, a container
+		//whose stream fails or stalls must not keep the others from being printed
+		streamCtx, stop := context.WithTimeout(ctx, noStreamTimeout)
+		defer stop()
+		samples, errs := con.StreamStats(streamCtx)
+		received := map[string]int{}
+	collect:
+		for done := 0; done < len(running); {
+			select {
+			case sample, ok := <-samples:
+				if !ok {
+					break collect
+				}
+				table.update(sample)
+				received[sample.Container]++
+				if received[sample.Container] == 2 {
+					done++
+				}
+			case err, ok := <-errs:
+				if ok {
+					PrintFatalError(NewStatsError(err))
+				}
+				errs = nil
+			case <-streamCtx.Done():
+				break collect
+			}
+		}
+		stop()
+		if errs != nil {
+			for err := range errs {
+				PrintFatalError(NewStatsError(err))
+			}
+		}
+		if err := renderStats(os.Stdout, statsFormat, table.rows(), true); err != nil {
+			PrintFatalError(NewStatsError(err))
+		}
+		return
+	}
+
+	samples, errs := con.StreamStats(ctx)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	header := true
+	for {
+		select {
+		case sample, ok := <-samples:
+			if !ok {
+				for err := range errs {
+					PrintFatalError(NewStatsError(err))
+				}
+				return
+			}
+			table.update(sample)
+		case <-ticker.C:
+			rows := table.rows()
+			if len(rows) == 0 {
+				continue
+			}
+			if statsFormat == "table" {
+				//clear the screen and move the cursor to the top left
+				fmt.Print("\033[H\033[2J")
+			}
+			if err := renderStats(os.Stdout, statsFormat, rows, header); err != nil {
+				PrintFatalError(NewStatsError(err))
+			}
+			header = statsFormat == "table"
+		}
+	}
+}
+
+// Keeps the latest formatted stats sample of each container
+type statsTable struct {
+	mu    sync.Mutex
+	stats map[string]response.FormatedContainerStats
+}
+
+func (t *statsTable) update(sample docker.StatsSample) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats[sample.Container] = *sample.Stats.Format()
+}
+
+func (t *statsTable) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.stats)
+}
+
+// Returns the latest samples sorted by container name
+func (t *statsTable) rows() []response.FormatedContainerStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rows := []response.FormatedContainerStats{}
+	for _, s := range t.stats {
+		rows = append(rows, s)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// Renders the stats rows in the given format, header is ignored by json
+func renderStats(w io.Writer, format string, rows []response.FormatedContainerStats, header bool) error {
+	switch format {
+	case "json":
+		b, err := json.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "csv":
+		cw := csv.NewWriter(w)
+		if header {
+			if err := cw.Write([]string{"ID", "NAME", "CPU", "MEMORY", "NETWORK", "DISK", "PIDS", "CPU THROTTLED"}); err != nil {
+				return err
+			}
+		}
+		for _, s := range rows {
+			if err := cw.Write([]string{s.ID, s.Name, s.CpuUsage, s.MemoryUsage, s.NetworkIO, s.DiskIO, s.PIDs, s.CpuThrottle}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tCPU %\tMEM USAGE / LIMIT\tNET I/O\tBLOCK I/O\tPIDS")
+		for _, s := range rows {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, s.CpuUsage, s.MemoryUsage, s.NetworkIO, s.DiskIO, s.PIDs)
+		}
+		return tw.Flush()
+	}
+}
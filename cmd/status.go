@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+	"github.com/ttacon/chalk"
+)
+
+var (
+	status = &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"ps"},
+		Short:   "Show the state of your local Conduit deployment services",
+		Run:     runStatus,
+	}
+)
+
+func init() {
+	deploy.AddCommand(status)
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewStatusError(err))
+		}
+	}
+	ctx := context.Background()
+	//read only so there is no need for a log consumer
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewStatusError(err))
+	}
+	statuses, err := con.Status(ctx)
+	if err != nil {
+		PrintFatalError(NewStatusError(err))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tENABLED\tSTATE\tHEALTH\tUPTIME\tRESTARTS\tPORTS")
+	missing := []string{}
+	for _, s := range statuses {
+		if s.IsMissing() {
+			missing = append(missing, s.Service)
+		}
+		state := orDash(s.State)
+		if s.IsMissing() {
+			state = "no container"
+		}
+		uptime := "-"
+		if d := s.Uptime(); d > 0 {
+			uptime = d.Round(time.Second).String()
+		}
+		restarts := "-"
+		if s.Container != "" {
+			restarts = strconv.Itoa(s.RestartCount)
+		}
+		fmt.Fprintf(w, "%s\t%t\t%s\t%s\t%s\t%s\t%s\n",
+			s.Service,
+			s.Enabled,
+			state,
+			orDash(s.Health),
+			uptime,
+			restarts,
+			orDash(strings.Join(s.Ports, ", ")),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		PrintFatalError(NewStatusError(err))
+	}
+	if len(missing) > 0 {
+		fmt.Println(chalk.Yellow.Color(fmt.Sprintf("\n%v are enabled but have no container.\nTry: goconduit deploy recreate", missing)))
+	}
+}
+
+// Returns a dash for empty table cells
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
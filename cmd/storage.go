@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	storageTo string
+
+	storage = &cobra.Command{
+		Use:   "storage",
+		Short: "Manage the database storage of your local Conduit deployment",
+		Run:   runDeploy,
+	}
+	storageMigrate = &cobra.Command{
+		Use:   "migrate",
+		Short: "Move the database between its named volume and a bind mount to ./database",
+		Run:   runStorageMigrate,
+	}
+)
+
+func init() {
+	deploy.AddCommand(storage)
+	storage.AddCommand(storageMigrate)
+	//deploy storage migrate
+	storageMigrate.PersistentFlags().StringVar(&storageTo, "to", "", "storage to migrate the database to either bind or volume")
+}
+
+func runStorageMigrate(cmd *cobra.Command, args []string) {
+	if storageTo != conduit.StorageBind && storageTo != conduit.StorageVolume {
+		PrintFatalError(NewStorageError(errors.New("use --to bind or --to volume")))
+	}
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewStorageError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewStorageError(err))
+	}
+	if err := con.MigrateStorage(ctx, storageTo); err != nil {
+		PrintFatalError(NewStorageError(err))
+	}
+	PrintSuccess(fmt.Sprintf("database migrated to %s storage", storageTo))
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateImageTag   string
+	updateUIImageTag string
+	skipBackup       bool
+	healthTimeout    time.Duration
+
+	update = &cobra.Command{
+		Use:   "update",
+		Short: "Update your local Conduit deployment to new image tags",
+		Run:   runUpdate,
+	}
+)
+
+func init() {
+	deploy.AddCommand(update)
+	//deploy update
+	update.PersistentFlags().StringVar(&updateImageTag, "image-tag", "", "set the conduit image tag to update to")
+	update.PersistentFlags().StringVar(&updateUIImageTag, "ui-image-tag", "", "set the conduit ui image tag to update to")
+	update.PersistentFlags().BoolVar(&skipBackup, "skip-backup", false, "do not snapshot the database before updating")
+	update.PersistentFlags().DurationVar(&healthTimeout, "health-timeout", 3*time.Minute, "how long to wait for the updated services to become healthy")
+}
+
+func runUpdate(cmd *cobra.Command, args []string) {
+	if updateImageTag == "" && updateUIImageTag == "" {
+		PrintFatalError(NewUpdateError(errors.New("no image tag given use --image-tag and/or --ui-image-tag")))
+	}
+	if !IsInProjectDirectory() {
+		if err := ChangeToProjectRootDir(); err != nil {
+			PrintFatalError(NewUpdateError(err))
+		}
+	}
+	ctx := context.Background()
+	con, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		PrintFatalError(NewUpdateError(err))
+	}
+	//update writes the tags to .env so a layer on top of it would keep the old ones
+	for _, tag := range []struct{ key, value string }{{"IMAGE_TAG", updateImageTag}, {"UI_IMAGE_TAG", updateUIImageTag}} {
+		if tag.value == "" {
+			continue
+		}
+		if source := overridingEnvSource(con, tag.key); source != "" {
+			PrintFatalError(NewUpdateError(fmt.Errorf("%s is overridden by %s, change or remove it there instead", tag.key, source)))
+		}
+	}
+	previousImages := con.Images()
+	previousImageTag, previousUIImageTag := con.ImageTags()
+	//remember the previous tags and .env before they are changed
+	if err := con.SetRollback(""); err != nil {
+		PrintFatalError(NewUpdateError(err))
+	}
+
+	con.SetImageTags(updateImageTag, updateUIImageTag)
+	if err := con.WriteEnvFile(); err != nil {
+		PrintFatalError(NewUpdateError(err))
+	}
+	//puts back the previous tags if anything fails before the containers are recreated
+	revert := func(err error) {
+		con.SetImageTags(previousImageTag, previousUIImageTag)
+		con.WriteEnvFile()
+		PrintFatalError(NewUpdateError(err))
+	}
+
+	//reload the project so the new tags are interpolated
+	updated, err := conduit.NewConduitFromProject(ctx, true, []string{}, composeFileOptions(cmd)...)
+	if err != nil {
+		revert(err)
+	}
+	changed := changedServices(previousImages, updated.Images())
+	if len(changed) == 0 {
+		PrintSuccess("already up to date")
+		return
+	}
+	if err := updated.Pull(ctx, changed); err != nil {
+		revert(err)
+	}
+	if !skipBackup && con.HasDatabase() {
+		snapshot, err := con.SnapshotDatabase(ctx, fmt.Sprintf("pre-update-%s", time.Now().Format("20060102150405")))
+		if err != nil {
+			revert(err)
+		}
+		con.GetRollback().Snapshot = snapshot
+	}
+	if err := con.WriteConduitJsonFile(); err != nil {
+		revert(err)
+	}
+	if err := updated.Create(ctx, changed); err != nil {
+		PrintFatalError(NewUpdateError(err))
+	}
+	if err := updated.Start(ctx, changed); err != nil {
+		PrintFatalError(NewUpdateError(fmt.Errorf("%s\nTry: goconduit deploy rollback", err)))
+	}
+	if err := updated.WaitHealthy(ctx, changed, healthTimeout, 10*time.Second); err != nil {
+		PrintFatalError(NewUpdateError(fmt.Errorf("%s\nTry: goconduit deploy rollback", err)))
+	}
+	PrintSuccess(fmt.Sprintf("updated %v", changed))
+}
+
+// Returns the services whose image differs between before and after sorted by name
+func changedServices(before, after map[string]string) []string {
+	changed := []string{}
+	for service, image := range after {
+		if before[service] != image {
+			changed = append(changed, service)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Returns the layer that overrides the value of the key in .env or "" when .env sets the value in use
+func overridingEnvSource(con *conduit.Conduit, key string) string {
+	values := con.EnvValues(key)
+	if len(values) == 0 {
+		return ""
+	}
+	if active := values[len(values)-1]; active.Source != "" && active.Source != ".env" {
+		return active.Source
+	}
+	return ""
+}
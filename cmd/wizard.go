@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+// Reports whether both stdin and stdout are terminals so questions can be asked
+func isInteractive() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+// Returns the database profile if one was given
+func databaseProfile(profiles []string) string {
+	for _, p := range profiles {
+		if p == "mongodb" || p == "postgres" {
+			return p
+		}
+	}
+	return ""
+}
+
+/*
+Asks for the setup options that were not given as flags, the flags that were given are used as is.
+The answers are written back to the setup flag variables. Returns false if the summary was not confirmed
+*/
+func runSetupWizard(cmd *cobra.Command, src composeopt.TemplateSource) (bool, error) {
+	databases, modules, err := conduit.TemplateProfiles(src)
+	if err != nil {
+		return false, err
+	}
+	if len(databases) == 0 {
+		return false, errors.New("the compose template has no database profiles")
+	}
+	flags := cmd.Flags()
+	questions := []*survey.Question{}
+	if !flags.Changed("project-name") {
+		questions = append(questions, &survey.Question{
+			Name:     "projectName",
+			Prompt:   &survey.Input{Message: "Project name:", Default: projectName},
+			Validate: survey.Required,
+		})
+	}
+	database := databaseProfile(profiles)
+	if database == "" {
+		questions = append(questions, &survey.Question{
+			Name:   "database",
+			Prompt: &survey.Select{Message: "Database:", Options: databases, Default: databases[0]},
+		})
+	}
+	if !flags.Changed("profiles") && len(modules) > 0 {
+		questions = append(questions, &survey.Question{
+			Name:   "modules",
+			Prompt: &survey.MultiSelect{Message: "Optional modules:", Options: modules},
+		})
+	}
+	if !flags.Changed("image-tag") {
+		questions = append(questions, &survey.Question{
+			Name:     "imageTag",
+			Prompt:   &survey.Input{Message: "Conduit image tag:", Default: imageTag},
+			Validate: survey.Required,
+		})
+	}
+	if !flags.Changed("ui-image-tag") {
+		questions = append(questions, &survey.Question{
+			Name:     "uiImageTag",
+			Prompt:   &survey.Input{Message: "Conduit UI image tag:", Default: uiImageTag},
+			Validate: survey.Required,
+		})
+	}
+	if !flags.Changed("mount-database") {
+		questions = append(questions, &survey.Question{
+			Name: "storage",
+			Prompt: &survey.Select{
+				Message: "Database storage:",
+				Options: []string{conduit.StorageVolume, conduit.StorageBind},
+				Default: conduit.StorageVolume,
+				Description: func(value string, index int) string {
+					if value == conduit.StorageBind {
+						return "bind mount to ./database"
+					}
+					return "docker named volume"
+				},
+			},
+		})
+	}
+	answers := struct {
+		ProjectName string
+		Database    string
+		Modules     []string
+		ImageTag    string
+		UIImageTag  string
+		Storage     string
+	}{}
+	if err := survey.Ask(questions, &answers); err != nil {
+		if errors.Is(err, terminal.InterruptErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	if answers.ProjectName != "" {
+		projectName = strings.TrimSpace(answers.ProjectName)
+	}
+	if answers.Database != "" {
+		profiles = append(profiles, answers.Database)
+	}
+	for _, module := range answers.Modules {
+		if !slices.Contains(profiles, module) {
+			profiles = append(profiles, module)
+		}
+	}
+	if answers.ImageTag != "" {
+		imageTag = answers.ImageTag
+	}
+	if answers.UIImageTag != "" {
+		uiImageTag = answers.UIImageTag
+	}
+	if answers.Storage != "" {
+		mountDatabase = answers.Storage == conduit.StorageBind
+	}
+
+	printSetupSummary()
+	confirmed := false
+	if err := survey.AskOne(&survey.Confirm{Message: "Create the project?", Default: true}, &confirmed); err != nil {
+		if errors.Is(err, terminal.InterruptErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	return confirmed, nil
+}
+
+func printSetupSummary() {
+	storage := conduit.StorageVolume
+	if mountDatabase {
+		storage = conduit.StorageBind
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "Project name\t%s\n", projectName)
+	fmt.Fprintf(tw, "Profiles\t%s\n", strings.Join(profiles, ", "))
+	fmt.Fprintf(tw, "Image tag\t%s\n", imageTag)
+	fmt.Fprintf(tw, "UI image tag\t%s\n", uiImageTag)
+	fmt.Fprintf(tw, "Database storage\t%s\n", storage)
+	fmt.Fprintln(tw)
+	tw.Flush()
+}
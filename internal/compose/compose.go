@@ -1,29 +1,39 @@
 package compose
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/compose-spec/compose-go/loader"
 	ctypes "github.com/compose-spec/compose-go/types"
+	"github.com/distribution/distribution/v3/reference"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/flags"
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/docker/compose/v2/pkg/compose"
+	dtypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
 	"github.com/isolateminds/go-conduit-cli/internal/compose/errordefs"
 	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+	"github.com/opencontainers/go-digest"
 	"golang.org/x/exp/slices"
 )
 
 type Composer struct {
 	project     *ctypes.Project
 	service     api.Service
+	cli         command.Cli
 	logConsumer api.LogConsumer
 	Options     *types.ComposerOptions
+	//profiles added automatically keyed by the profiles that require them
+	required map[string][]string
 }
 
 func (c *Composer) AllServicesNames() []string {
@@ -49,6 +59,28 @@ func (c *Composer) Start(ctx context.Context, services []string) error {
 	})
 }
 
+// Returns the services enabled by any of the profiles including the ones disabled by the active profiles
+func (c *Composer) ProfileServices(profiles ...string) []string {
+	services := []string{}
+	for _, service := range c.project.AllServices() {
+		for _, profile := range service.Profiles {
+			if slices.Contains(profiles, profile) {
+				services = append(services, service.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Returns every profile defined in the yaml
+func (c *Composer) ProfileNames() []string {
+	profiles := c.project.AllServices().GetProfiles()
+	sort.Strings(profiles)
+	return profiles
+}
+
 // Filters the underlying yaml profiles with the provided ones
 // and returns the ones that only exist within the yaml - docker-compose.yaml
 func (c *Composer) FilterYamlProfiles(profiles []string) []string {
@@ -114,10 +146,268 @@ func (c *Composer) Up(ctx context.Context) error {
 	return nil
 }
 
-func (c *Composer) Config(ctx context.Context) ([]byte, error) {
-	return c.service.Config(ctx, c.project, api.ConfigOptions{
-		Format: "yaml",
+// Like Up but only recreates the containers whose configuration diverged from the yaml
+func (c *Composer) Reconcile(ctx context.Context) error {
+	err := c.service.Up(ctx, c.project, api.UpOptions{
+		Create: api.CreateOptions{
+			Services:             c.project.ServiceNames(),
+			Recreate:             api.RecreateDiverged,
+			RecreateDependencies: api.RecreateDiverged,
+		},
+		Start: api.StartOptions{
+			Attach:   c.logConsumer,
+			Project:  c.project,
+			Services: c.project.ServiceNames(),
+			AttachTo: c.project.ServiceNames(),
+		},
+	})
+	if err != nil {
+		return errordefs.NewComposerUpError(err)
+	}
+	return nil
+}
+
+// Lists every service defined in the yaml including the ones disabled by profiles
+// along with the state of their containers
+func (c *Composer) Status(ctx context.Context) ([]types.ServiceStatus, error) {
+	containers, err := c.service.Ps(ctx, c.project.Name, api.PsOptions{
+		Project: c.project,
+		All:     true,
+	})
+	if err != nil {
+		return nil, errordefs.NewComposerStatusError(err)
+	}
+	byService := map[string]api.ContainerSummary{}
+	for _, container := range containers {
+		byService[container.Service] = container
+	}
+	result := []types.ServiceStatus{}
+	for _, s := range c.project.AllServices() {
+		status := types.ServiceStatus{
+			Service:  s.Name,
+			Profiles: s.Profiles,
+			Enabled:  slices.Contains(c.project.ServiceNames(), s.Name),
+		}
+		if container, ok := byService[s.Name]; ok {
+			inspect, err := c.Options.Client.ContainerInspect(ctx, container.ID)
+			if err != nil {
+				return nil, errordefs.NewComposerStatusError(err)
+			}
+			status.Container = container.Name
+			status.State = container.State
+			status.Health = container.Health
+			status.RestartCount = inspect.RestartCount
+			if inspect.State != nil {
+				status.StartedAt, _ = time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+			}
+			for _, p := range container.Publishers {
+				if p.PublishedPort == 0 {
+					status.Ports = append(status.Ports, fmt.Sprintf("%d/%s", p.TargetPort, p.Protocol))
+					continue
+				}
+				status.Ports = append(status.Ports, fmt.Sprintf("%s:%d->%d/%s", p.URL, p.PublishedPort, p.TargetPort, p.Protocol))
+			}
+		}
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Service < result[j].Service
+	})
+	return result, nil
+}
+
+// Streams the logs of the project containers to the log consumer
+func (c *Composer) Logs(ctx context.Context, options types.LogOptions) error {
+	if c.logConsumer == nil {
+		return errordefs.NewComposerLogsError(errors.New("no log consumer provided"))
+	}
+	err := c.checkServices(options.Services)
+	if err != nil {
+		return errordefs.NewComposerLogsError(err)
+	}
+	err = c.service.Logs(ctx, c.project.Name, c.logConsumer, api.LogOptions{
+		Project:    c.project,
+		Services:   options.Services,
+		Tail:       options.Tail,
+		Since:      options.Since,
+		Until:      options.Until,
+		Follow:     options.Follow,
+		Timestamps: options.Timestamps,
+	})
+	if err != nil {
+		return errordefs.NewComposerLogsError(err)
+	}
+	return nil
+}
+
+// Resolves the running container of the service by its compose labels and returns the container name
+func (c *Composer) ServiceContainer(ctx context.Context, service string) (string, error) {
+	err := c.checkServices([]string{service})
+	if err != nil {
+		return "", err
+	}
+	containers, err := c.Options.Client.ContainerList(ctx, dtypes.ContainerListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", fmt.Sprintf("%s=%s", api.ProjectLabel, strings.ToLower(c.project.Name))),
+			filters.Arg("label", fmt.Sprintf("%s=%s", api.ServiceLabel, service)),
+			filters.Arg("label", fmt.Sprintf("%s=%s", api.OneoffLabel, "False")),
+			filters.Arg("status", "running"),
+		),
+	})
+	if err != nil {
+		return "", errordefs.NewComposerError(err)
+	}
+	if len(containers) == 0 || len(containers[0].Names) == 0 {
+		return "", errordefs.NewComposerError(fmt.Errorf("service %s has no running container", service))
+	}
+	return strings.TrimPrefix(containers[0].Names[0], "/"), nil
+}
+
+// Runs a one-off container from the service definition labelled as one-off and returns its exit code
+func (c *Composer) Run(ctx context.Context, options types.RunOptions) (int, error) {
+	err := c.checkServices([]string{options.Service})
+	if err != nil {
+		return -1, errordefs.NewComposerRunError(err)
+	}
+	project := c.runProject(options)
+	code, err := c.service.RunOneOffContainer(ctx, project, api.RunOptions{
+		Project:     project,
+		Service:     options.Service,
+		Command:     options.Command,
+		Entrypoint:  options.Entrypoint,
+		Environment: options.Environment,
+		AutoRemove:  options.AutoRemove,
+		Tty:         options.Tty,
+		Interactive: options.Interactive,
+		//like docker compose run the container only takes the traffic of the service when asked to
+		UseNetworkAliases: options.UseAliases,
+	})
+	if err != nil {
+		return -1, errordefs.NewComposerRunError(err)
+	}
+	return code, nil
+}
+
+// Returns a copy of the project with the service of the one-off container adjusted to the options so the
+// composer keeps the service as loaded
+func (c *Composer) runProject(options types.RunOptions) *ctypes.Project {
+	project := *c.project
+	project.Services = append(ctypes.Services{}, c.project.Services...)
+	for i, service := range project.Services {
+		if service.Name != options.Service {
+			continue
+		}
+		//the ports are published by the running service, publishing them again fails as already allocated
+		if !options.ServicePorts {
+			service.Ports = nil
+		}
+		project.Services[i] = service
+	}
+	return &project
+}
+
+// Returns the images of the enabled services keyed by service name
+func (c *Composer) Images() map[string]string {
+	images := map[string]string{}
+	for _, s := range c.project.Services {
+		images[s.Name] = s.Image
+	}
+	return images
+}
+
+// Pulls the images of the services with progress output, pulls every enabled service if none are given
+func (c *Composer) Pull(ctx context.Context, services []string) error {
+	err := c.checkServices(services)
+	if err != nil {
+		return errordefs.NewComposerPullError(err)
+	}
+	//shallow copy so only the requested services are pulled
+	project := *c.project
+	if len(services) > 0 {
+		project.Services = ctypes.Services{}
+		for _, s := range c.project.Services {
+			if slices.Contains(services, s.Name) {
+				project.Services = append(project.Services, s)
+			}
+		}
+	}
+	err = c.service.Pull(ctx, &project, api.PullOptions{})
+	if err != nil {
+		return errordefs.NewComposerPullError(err)
+	}
+	return nil
+}
+
+// Returns the volume and bind mounts of the service
+func (c *Composer) ServiceMounts(service string) ([]types.Mount, error) {
+	s, err := c.project.GetService(service)
+	if err != nil {
+		return nil, errordefs.NewComposerError(err)
+	}
+	mounts := []types.Mount{}
+	for _, v := range s.Volumes {
+		switch v.Type {
+		case ctypes.VolumeTypeVolume:
+			source := v.Source
+			if volume, ok := c.project.Volumes[v.Source]; ok && volume.Name != "" {
+				source = volume.Name
+			}
+			mounts = append(mounts, types.Mount{Type: v.Type, Source: source, Target: v.Target})
+		case ctypes.VolumeTypeBind:
+			mounts = append(mounts, types.Mount{Type: v.Type, Source: v.Source, Target: v.Target})
+		}
+	}
+	return mounts, nil
+}
+
+// Returns the docker volume names of the project keyed by the name used in the compose file eg: mongo -> conduit_mongo
+func (c *Composer) Volumes() map[string]string {
+	volumes := map[string]string{}
+	for key, volume := range c.project.Volumes {
+		name := volume.Name
+		if name == "" {
+			name = fmt.Sprintf("%s_%s", c.project.Name, key)
+		}
+		volumes[key] = name
+	}
+	return volumes
+}
+
+// Returns the project after .env interpolation and profile application as yaml or json.
+// The dollar signs of the yaml are escaped so it can be used as a compose file again
+func (c *Composer) Config(ctx context.Context, format string) ([]byte, error) {
+	b, err := c.service.Config(ctx, c.project, api.ConfigOptions{
+		Format: format,
 	})
+	if err != nil {
+		return nil, err
+	}
+	if format != "yaml" {
+		return b, nil
+	}
+	return bytes.ReplaceAll(b, []byte("$"), []byte("$$")), nil
+}
+
+// Replaces the image tags of the enabled services with the digests the registries resolve them to
+func (c *Composer) ResolveImageDigests(ctx context.Context) error {
+	return c.project.ResolveImages(func(named reference.Named) (digest.Digest, error) {
+		auth, err := command.RetrieveAuthTokenFromImage(ctx, c.cli, named.String())
+		if err != nil {
+			return "", err
+		}
+		inspect, err := c.Options.Client.DistributionInspect(ctx, named.String(), auth)
+		if err != nil {
+			return "", err
+		}
+		return inspect.Descriptor.Digest, nil
+	})
+}
+
+// Returns the names of the enabled services sorted
+func (c *Composer) ServiceNames() []string {
+	services := c.project.ServiceNames()
+	sort.Strings(services)
+	return services
 }
 func NewComposer(name string, setComposerOptions ...composeopt.SetComposerOptions) (*Composer, error) {
 	options := &types.ComposerOptions{}
@@ -130,6 +420,9 @@ func NewComposer(name string, setComposerOptions ...composeopt.SetComposerOption
 			return nil, errordefs.NewComposerError(err)
 		}
 	}
+	if err := composeopt.WithYamlOverrideFiles(options.ComposeFiles...)(options); err != nil {
+		return nil, errordefs.NewComposerError(err)
+	}
 
 	if options.Client == nil {
 		return nil, errordefs.NewComposerError(errors.New("no client provided"))
@@ -140,12 +433,21 @@ func NewComposer(name string, setComposerOptions ...composeopt.SetComposerOption
 		return nil, errordefs.NewComposerError(errors.New("no yaml provided"))
 	} else {
 		ctx := context.Background()
-		configFile := ctypes.ConfigFile{
-			Content: options.Yaml.Bytes,
+		//the overrides are merged on top of the yaml in order
+		configFiles := []ctypes.ConfigFile{}
+		composeFiles := []string{}
+		for _, yaml := range append([]*types.Yaml{options.Yaml}, options.Overrides...) {
+			configFiles = append(configFiles, ctypes.ConfigFile{
+				Filename: yaml.Filename,
+				Content:  yaml.Bytes,
+			})
+			if yaml.Filename != "" {
+				composeFiles = append(composeFiles, yaml.Filename)
+			}
 		}
 		configDetails := ctypes.ConfigDetails{
 			Environment: options.Environment.Variables,
-			ConfigFiles: []ctypes.ConfigFile{configFile},
+			ConfigFiles: configFiles,
 		}
 		project, err := loader.LoadWithContext(ctx, configDetails, func(o *loader.Options) {
 			o.SetProjectName(options.Name, true)
@@ -153,7 +455,14 @@ func NewComposer(name string, setComposerOptions ...composeopt.SetComposerOption
 		if err != nil {
 			return nil, errordefs.NewComposerError(err)
 		}
+		//include the profiles the requested ones depend on
+		profiles, required, err := resolveProfiles(project, options.Profiles)
+		if err != nil {
+			return nil, errordefs.NewComposerError(err)
+		}
+		options.Profiles = profiles
 		project.ApplyProfiles(options.Profiles)
+		project.ComposeFiles = composeFiles
 		//Sets the proper docker compose labels this is how docker desktop
 		//knows it's a compose project
 		for i, s := range project.Services {
@@ -182,8 +491,10 @@ func NewComposer(name string, setComposerOptions ...composeopt.SetComposerOption
 		return &Composer{
 			project:     project,
 			service:     service,
+			cli:         cli,
 			Options:     options,
 			logConsumer: options.LogConsumer,
+			required:    required,
 		}, nil
 	}
 }
@@ -5,13 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"sync"
 
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
 	"github.com/isolateminds/go-conduit-cli/internal/docker"
+	"github.com/isolateminds/go-conduit-cli/internal/fetcher"
 	"github.com/isolateminds/go-conduit-cli/pkg/conduit/errordefs"
 	"github.com/joho/godotenv"
 )
@@ -47,6 +47,54 @@ func WithYamlFromFile(src string) SetComposerOptions {
 	}
 }
 
+// The name docker compose gives the file it layers on top of the compose file automatically
+const DefaultOverrideFile = "docker-compose.override.yaml"
+
+// Layers the files on top of the yaml in order so the later ones win
+func WithYamlOverrideFiles(srcs ...string) SetComposerOptions {
+	return func(opt *types.ComposerOptions) error {
+		for _, src := range srcs {
+			yaml, err := types.LoadYamlFromFile(src)
+			if err != nil {
+				return errordefs.NewYamlFileError(err)
+			}
+			opt.Overrides = append(opt.Overrides, yaml)
+		}
+		return nil
+	}
+}
+
+// Sets the files layered on top of the overrides replacing the ones set by an earlier option, none layers none
+func WithComposeFiles(srcs ...string) SetComposerOptions {
+	return func(opt *types.ComposerOptions) error {
+		opt.ComposeFiles = srcs
+		return nil
+	}
+}
+
+// Layers docker-compose.override.yaml (or .yml) from the current directory on top of the yaml if it exists
+func WithDefaultYamlOverrideFile() SetComposerOptions {
+	return func(opt *types.ComposerOptions) error {
+		for _, src := range []string{DefaultOverrideFile, "docker-compose.override.yml"} {
+			if _, err := os.Stat(src); err == nil {
+				return WithYamlOverrideFiles(src)(opt)
+			}
+		}
+		return nil
+	}
+}
+
+// Loads src with src.local, src.<environment> and the process environment layered on top, see types.NewLayeredEnvFromFile
+func WithLayeredEnvFromFile(src, environment string) SetComposerOptions {
+	return func(opt *types.ComposerOptions) (err error) {
+		opt.Environment, err = types.NewLayeredEnvFromFile(src, environment)
+		if err != nil {
+			return errordefs.NewEnvFileError(err)
+		}
+		return nil
+	}
+}
+
 func WithEnvFromFile(src string) SetComposerOptions {
 	return func(opt *types.ComposerOptions) (err error) {
 		opt.Environment, err = types.NewEnvFromFile(src)
@@ -87,12 +135,7 @@ func WithYamlFromUrlFormatter(url string, parser YamlFormatter) SetComposerOptio
 		if parser == nil {
 			return fmt.Errorf("parser must not be %v", parser)
 		}
-		res, err := http.Get(url)
-		if err != nil {
-			return errordefs.NewYamlFileError(err)
-		}
-		defer res.Body.Close()
-		data, err := io.ReadAll(res.Body)
+		data, err := fetcher.Fetch(context.Background(), url)
 		if err != nil {
 			return errordefs.NewYamlFileError(err)
 		}
@@ -113,12 +156,7 @@ func WithEnvFromUrlFormatter(url string, formatter EnvFormatter) SetComposerOpti
 		if formatter == nil {
 			return fmt.Errorf("formatter must not be %v", formatter)
 		}
-		res, err := http.Get(url)
-		if err != nil {
-			return errordefs.NewEnvFileError(err)
-		}
-		defer res.Body.Close()
-		data, err := io.ReadAll(res.Body)
+		data, err := fetcher.Fetch(context.Background(), url)
 		if err != nil {
 			return errordefs.NewEnvFileError(err)
 		}
@@ -175,6 +213,12 @@ func WithCustomLogConsumer(consumer api.LogConsumer) SetComposerOptions {
 
 // The default docker compose logger when --detach flag is not zeroed
 func WithDefaultComposeLogConsumer(ctx context.Context) SetComposerOptions {
+	return WithComposeLogConsumer(ctx, true)
+}
+
+// The docker compose logger with colored output toggled, use this when the default colors are unwanted
+// such as when piping output to a file
+func WithComposeLogConsumer(ctx context.Context, color bool) SetComposerOptions {
 	return func(opt *types.ComposerOptions) error {
 		opt.LogConsumer = &logConsumer{
 			ctx:        ctx,
@@ -182,7 +226,7 @@ func WithDefaultComposeLogConsumer(ctx context.Context) SetComposerOptions {
 			width:      0,
 			stdout:     os.Stdout,
 			stderr:     os.Stderr,
-			color:      true,
+			color:      color,
 			prefix:     true,
 			timestamp:  false,
 		}
@@ -0,0 +1,126 @@
+package composeopt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+	"github.com/isolateminds/go-conduit-cli/internal/fetcher"
+	"github.com/isolateminds/go-conduit-cli/pkg/conduit/errordefs"
+	"github.com/isolateminds/go-conduit-cli/templates"
+	"github.com/joho/godotenv"
+)
+
+// Where the docker compose and .env templates are read from
+type TemplateSource interface {
+	ReadTemplate(name string) ([]byte, error)
+	// Describes the source for error messages
+	String() string
+}
+
+type fsTemplateSource struct {
+	fsys        fs.FS
+	description string
+}
+
+func (s *fsTemplateSource) ReadTemplate(name string) ([]byte, error) {
+	return fs.ReadFile(s.fsys, name)
+}
+
+func (s *fsTemplateSource) String() string {
+	return s.description
+}
+
+type urlTemplateSource struct {
+	baseURL string
+	fetcher *fetcher.Fetcher
+}
+
+func (s *urlTemplateSource) ReadTemplate(name string) ([]byte, error) {
+	return s.fetcher.Fetch(context.Background(), fmt.Sprintf("%s/%s", s.baseURL, name))
+}
+
+func (s *urlTemplateSource) String() string {
+	return s.baseURL
+}
+
+// Reports whether reading a template failed because the source does not have it
+func IsTemplateNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, fetcher.ErrNotFound)
+}
+
+// The templates embedded in the binary, this is the default source and works offline
+func EmbeddedTemplateSource() TemplateSource {
+	return &fsTemplateSource{fsys: templates.FS, description: "embedded templates"}
+}
+
+// Reads the templates from a local directory eg: a checkout of the templates directory
+func DirTemplateSource(dir string) TemplateSource {
+	return &fsTemplateSource{fsys: os.DirFS(dir), description: dir}
+}
+
+/*
+Fetches the templates relative to the base url, the fetcher options can be used to verify
+the templates against a checksum manifest
+
+	//fetches https://example.com/templates/docker-compose.yml
+	composeopt.URLTemplateSource("https://example.com/templates", fetcher.WithManifest(manifest))
+*/
+func URLTemplateSource(baseURL string, options ...fetcher.SetFetcherOptions) TemplateSource {
+	return &urlTemplateSource{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		fetcher: fetcher.NewFetcher(options...),
+	}
+}
+
+// Reads the yaml template from the source and runs the formatter when one is given
+func WithYamlFromTemplate(src TemplateSource, name string, formatter YamlFormatter) SetComposerOptions {
+	return func(opt *types.ComposerOptions) error {
+		b, err := src.ReadTemplate(name)
+		if err != nil {
+			return errordefs.NewYamlFileError(fmt.Errorf("%s from %s: %s", name, src, err))
+		}
+		if formatter != nil {
+			if b, err = formatter.Format(b); err != nil {
+				return errordefs.NewYamlFileError(err)
+			}
+		}
+		opt.Yaml = &types.Yaml{
+			Bytes: b,
+		}
+		return nil
+	}
+}
+
+// Reads the env template from the source and runs the formatter when one is given
+func WithEnvFromTemplate(src TemplateSource, name string, formatter EnvFormatter) SetComposerOptions {
+	return func(opt *types.ComposerOptions) error {
+		b, err := src.ReadTemplate(name)
+		if err != nil {
+			return errordefs.NewEnvFileError(fmt.Errorf("%s from %s: %s", name, src, err))
+		}
+		if formatter != nil {
+			formatted, err := formatter.Format(b)
+			if err != nil {
+				return errordefs.NewEnvFileError(err)
+			}
+			if b, err = io.ReadAll(formatted); err != nil {
+				return errordefs.NewEnvFileError(err)
+			}
+		}
+		parsed, err := godotenv.UnmarshalBytes(b)
+		if err != nil {
+			return errordefs.NewEnvFileError(err)
+		}
+		opt.Environment = &types.Environment{
+			Bytes:     b,
+			Variables: parsed,
+		}
+		return nil
+	}
+}
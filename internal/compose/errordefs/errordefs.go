@@ -36,6 +36,38 @@ func (e composerUpError) Error() string {
 	return fmt.Sprintf("ComposerUpError: %s", e.message)
 }
 
+type composerStatusError struct {
+	message string
+}
+
+func (e composerStatusError) Error() string {
+	return fmt.Sprintf("ComposerStatusError: %s", e.message)
+}
+
+type composerLogsError struct {
+	message string
+}
+
+func (e composerLogsError) Error() string {
+	return fmt.Sprintf("ComposerLogsError: %s", e.message)
+}
+
+type composerRunError struct {
+	message string
+}
+
+func (e composerRunError) Error() string {
+	return fmt.Sprintf("ComposerRunError: %s", e.message)
+}
+
+type composerPullError struct {
+	message string
+}
+
+func (e composerPullError) Error() string {
+	return fmt.Sprintf("ComposerPullError: %s", e.message)
+}
+
 // Generic Composer Errors
 func NewComposerError(err error) error {
 	return &newComposerError{message: err.Error()}
@@ -55,3 +87,23 @@ func NewComposerStopError(err error) error {
 func NewComposerUpError(err error) error {
 	return &composerUpError{message: err.Error()}
 }
+
+// Errors that occur when invoking Status function
+func NewComposerStatusError(err error) error {
+	return &composerStatusError{message: err.Error()}
+}
+
+// Errors that occur when invoking Logs function
+func NewComposerLogsError(err error) error {
+	return &composerLogsError{message: err.Error()}
+}
+
+// Errors that occur when invoking Run function
+func NewComposerRunError(err error) error {
+	return &composerRunError{message: err.Error()}
+}
+
+// Errors that occur when invoking Pull function
+func NewComposerPullError(err error) error {
+	return &composerPullError{message: err.Error()}
+}
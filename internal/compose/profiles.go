@@ -0,0 +1,152 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	ctypes "github.com/compose-spec/compose-go/types"
+	"golang.org/x/exp/slices"
+)
+
+// Returns the profiles after resolving them against the yaml and the profiles that were added
+// automatically keyed by the profiles that require them
+func (c *Composer) ResolveProfiles(profiles []string) (resolved []string, required map[string][]string, err error) {
+	return resolveProfiles(c.project, profiles)
+}
+
+// The profiles the composer was created with including the ones required by their dependencies
+func (c *Composer) Profiles() []string {
+	return append([]string{}, c.Options.Profiles...)
+}
+
+// The profiles that were added automatically keyed by the profiles that require them
+func (c *Composer) RequiredProfiles() map[string][]string {
+	return c.required
+}
+
+/*
+Walks the depends_on of the services each profile enables and adds the profiles of the dependencies
+that are not enabled yet eg: forms depends on email so the email profile is added when forms is.
+Unknown profiles are an error with a suggestion when one of the defined profiles is close enough
+*/
+func resolveProfiles(project *ctypes.Project, profiles []string) (resolved []string, required map[string][]string, err error) {
+	services := project.AllServices()
+	defined := services.GetProfiles()
+	resolved, required = []string{}, map[string][]string{}
+	for _, profile := range profiles {
+		if slices.Contains(resolved, profile) {
+			continue
+		}
+		if !slices.Contains(defined, profile) {
+			return nil, nil, unknownProfileError(profile, defined)
+		}
+		resolved = append(resolved, profile)
+	}
+	byName := map[string]ctypes.ServiceConfig{}
+	for _, service := range services {
+		byName[service.Name] = service
+	}
+	//services whose dependencies still have to be checked along with the profile that enabled them
+	type pending struct {
+		service string
+		profile string
+	}
+	queue := []pending{}
+	enqueue := func(profile string) {
+		for _, service := range services {
+			if slices.Contains(service.Profiles, profile) {
+				queue = append(queue, pending{service.Name, profile})
+			}
+		}
+	}
+	for _, profile := range resolved {
+		enqueue(profile)
+	}
+	visited := map[string]bool{}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if visited[next.service] {
+			continue
+		}
+		visited[next.service] = true
+		dependencies := []string{}
+		for dependency := range byName[next.service].DependsOn {
+			dependencies = append(dependencies, dependency)
+		}
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			service, ok := byName[dependency]
+			if !ok {
+				continue
+			}
+			if len(service.Profiles) > 0 && !containsAny(resolved, service.Profiles) {
+				profile := service.Profiles[0]
+				resolved = append(resolved, profile)
+				required[profile] = append(required[profile], next.profile)
+				enqueue(profile)
+			}
+			queue = append(queue, pending{dependency, next.profile})
+		}
+	}
+	return resolved, required, nil
+}
+
+func containsAny(values []string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if slices.Contains(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func unknownProfileError(profile string, defined []string) error {
+	sorted := append([]string{}, defined...)
+	sort.Strings(sorted)
+	if suggestion := closestProfile(profile, sorted); suggestion != "" {
+		return fmt.Errorf("unknown profile %s did you mean %s? defined profiles are %s", profile, suggestion, strings.Join(sorted, ", "))
+	}
+	return fmt.Errorf("unknown profile %s defined profiles are %s", profile, strings.Join(sorted, ", "))
+}
+
+// Returns the profile with the smallest edit distance to name or "" if none of them is close enough
+func closestProfile(name string, profiles []string) string {
+	best, bestDistance := "", -1
+	for _, profile := range profiles {
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(profile))
+		if bestDistance == -1 || distance < bestDistance {
+			best, bestDistance = profile, distance
+		}
+	}
+	limit := len(name) / 3
+	if limit < 2 {
+		limit = 2
+	}
+	if best == "" || bestDistance > limit {
+		return ""
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current := make([]int, len(rb)+1)
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(rb)]
+}
@@ -1,12 +1,17 @@
 package types
 
 import (
-	"io"
-	"net/http"
+	"bytes"
+	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"time"
 
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/docker/docker/client"
+	"github.com/isolateminds/go-conduit-cli/internal/fetcher"
 	"github.com/joho/godotenv"
 )
 
@@ -16,6 +21,104 @@ type Environment struct {
 	Bytes []byte
 	// Key/Value pairs
 	Variables map[string]string
+	// The layers Variables were merged from, lowest precedence first. Empty when there is a single source
+	Sources []EnvSource
+}
+
+// Name of the layer holding the process environment overrides
+const ProcessEnvSource = "process environment"
+
+// One of the layers the variables of an environment are merged from
+type EnvSource struct {
+	// The file name or ProcessEnvSource
+	Name      string
+	Variables map[string]string
+}
+
+// The value a layer sets for a variable
+type EnvValue struct {
+	Source string
+	Value  string
+	// Whether this is the value used, the last layer defining the variable wins
+	Active bool
+}
+
+/*
+Sets the value of the key while preserving comments, whitespace and order of the env bytes.
+If the key doesn't exist yet it is appended to the end
+*/
+func (e *Environment) Set(key, value string) {
+	line := []byte(fmt.Sprintf("%s=\"%s\"", key, value))
+	re := regexp.MustCompile(`(?m)^[ \t]*(export[ \t]+)?` + regexp.QuoteMeta(key) + `[ \t]*=.*$`)
+	if re.Match(e.Bytes) {
+		e.Bytes = re.ReplaceAllFunc(e.Bytes, func([]byte) []byte { return line })
+	} else {
+		if len(e.Bytes) > 0 && !bytes.HasSuffix(e.Bytes, []byte("\n")) {
+			e.Bytes = append(e.Bytes, '\n')
+		}
+		e.Bytes = append(append(e.Bytes, line...), '\n')
+	}
+	if e.Variables == nil {
+		e.Variables = map[string]string{}
+	}
+	if len(e.Sources) == 0 {
+		e.Variables[key] = value
+		return
+	}
+	//the bytes are the first layer, a later layer defining the key still wins
+	e.Sources[0].Variables[key] = value
+	for _, source := range e.Sources[1:] {
+		if _, ok := source.Variables[key]; ok {
+			return
+		}
+	}
+	e.Variables[key] = value
+}
+
+// Removes the key from the env bytes, a later layer defining the key still sets it
+func (e *Environment) Unset(key string) {
+	re := regexp.MustCompile(`(?m)^[ \t]*(export[ \t]+)?` + regexp.QuoteMeta(key) + `[ \t]*=.*(\n|$)`)
+	e.Bytes = re.ReplaceAll(e.Bytes, nil)
+	delete(e.Variables, key)
+	if len(e.Sources) == 0 {
+		return
+	}
+	delete(e.Sources[0].Variables, key)
+	for _, source := range e.Sources[1:] {
+		if value, ok := source.Variables[key]; ok {
+			e.Variables[key] = value
+		}
+	}
+}
+
+// Returns the value the env bytes set for the key ignoring the later layers
+func (e *Environment) Lookup(key string) (string, bool) {
+	if len(e.Sources) == 0 {
+		value, ok := e.Variables[key]
+		return value, ok
+	}
+	value, ok := e.Sources[0].Variables[key]
+	return value, ok
+}
+
+// Returns the value every layer sets for the key, lowest precedence first
+func (e *Environment) Values(key string) []EnvValue {
+	values := []EnvValue{}
+	if len(e.Sources) == 0 {
+		if value, ok := e.Variables[key]; ok {
+			values = append(values, EnvValue{Value: value, Active: true})
+		}
+		return values
+	}
+	for _, source := range e.Sources {
+		if value, ok := source.Variables[key]; ok {
+			values = append(values, EnvValue{Source: source.Name, Value: value})
+		}
+	}
+	if len(values) > 0 {
+		values[len(values)-1].Active = true
+	}
+	return values
 }
 
 // writes key-value pairs to the specified file destination.
@@ -45,19 +148,68 @@ func NewEnvFromFile(src string) (*Environment, error) {
 	}, nil
 }
 
-// This function is useful when you need to load environment variables
-// from an external source, such as from a GET request response body.
-func NewEnvFromURL(url string) (env *Environment, err error) {
-	res, err := http.Get(url)
+/*
+Loads src then merges src.local and src.<environment> (when environment is not empty) on top if they exist
+followed by the process environment for the variables the files define, the later layers win.
+The bytes are the ones of src so writing the environment back never copies the other layers into it
+*/
+func NewLayeredEnvFromFile(src, environment string) (*Environment, error) {
+	env, err := NewEnvFromFile(src)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
-	b, err := io.ReadAll(res.Body)
+	env.Sources = []EnvSource{{Name: src, Variables: copyVariables(env.Variables)}}
+	layers := []string{src + ".local"}
+	if environment != "" {
+		layers = append(layers, src+"."+environment)
+	}
+	for _, layer := range layers {
+		if _, err := os.Stat(layer); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		variables, err := godotenv.Read(layer)
+		if err != nil {
+			return nil, err
+		}
+		env.Sources = append(env.Sources, EnvSource{Name: layer, Variables: variables})
+		for key, value := range variables {
+			env.Variables[key] = value
+		}
+	}
+	process := map[string]string{}
+	for key := range env.Variables {
+		if value, ok := os.LookupEnv(key); ok {
+			process[key] = value
+		}
+	}
+	if len(process) > 0 {
+		env.Sources = append(env.Sources, EnvSource{Name: ProcessEnvSource, Variables: process})
+		for key, value := range process {
+			env.Variables[key] = value
+		}
+	}
+	return env, nil
+}
+
+func copyVariables(variables map[string]string) map[string]string {
+	copied := make(map[string]string, len(variables))
+	for key, value := range variables {
+		copied[key] = value
+	}
+	return copied
+}
+
+// This function is useful when you need to load environment variables
+// from an external source, such as from a GET request response body.
+func NewEnvFromURL(url string) (env *Environment, err error) {
+	b, err := fetcher.Fetch(context.Background(), url)
 	if err != nil {
 		return nil, err
 	}
-	kvPairs, err := godotenv.Parse(res.Body)
+	kvPairs, err := godotenv.UnmarshalBytes(b)
 	if err != nil {
 		return
 	}
@@ -69,6 +221,8 @@ func NewEnvFromURL(url string) (env *Environment, err error) {
 
 type Yaml struct {
 	Bytes []byte
+	// Absolute path of the file the yaml was read from, empty when it was rendered or fetched
+	Filename string
 }
 
 // Implements stringer interface
@@ -82,21 +236,20 @@ func LoadYamlFromFile(src string) (yaml *Yaml, err error) {
 	if err != nil {
 		return
 	}
+	filename, err := filepath.Abs(src)
+	if err != nil {
+		return
+	}
 	return &Yaml{
-		Bytes: b,
+		Bytes:    b,
+		Filename: filename,
 	}, nil
 }
 
 // This function is useful when you need to load yaml files
 // from an external source, such as from a GET request response body.
 func LoadYamlFromURL(url string) (yaml *Yaml, err error) {
-	res, err := http.Get(url)
-	if err != nil {
-		return
-	}
-	defer res.Body.Close()
-
-	b, err := io.ReadAll(res.Body)
+	b, err := fetcher.Fetch(context.Background(), url)
 	if err != nil {
 		return
 	}
@@ -105,11 +258,79 @@ func LoadYamlFromURL(url string) (yaml *Yaml, err error) {
 	}, nil
 }
 
+// A service mount with named volumes resolved to their docker volume names
+// and bind mounts resolved to absolute paths
+type Mount struct {
+	// Either volume or bind
+	Type   string
+	Source string
+	Target string
+}
+
+// Options for reading the logs of already created containers
+type LogOptions struct {
+	Services []string
+	// Number of lines to show from the end of the logs, "all" for everything
+	Tail       string
+	Since      string
+	Until      string
+	Follow     bool
+	Timestamps bool
+}
+
+// Options for running a one-off container from a service definition
+type RunOptions struct {
+	Service    string
+	Command    []string
+	Entrypoint []string
+	// Environment variable overrides in the form of KEY=VALUE
+	Environment []string
+	// Remove the container once it exits
+	AutoRemove  bool
+	Tty         bool
+	Interactive bool
+	// Publish the ports of the service, off by default so the container can run next to the service
+	ServicePorts bool
+	// Register the service aliases on the networks so the other services reach the container by them
+	UseAliases bool
+}
+
 type ComposerOptions struct {
 	Name        string
 	Client      client.APIClient
 	Environment *Environment
 	Yaml        *Yaml
-	Profiles    []string
-	LogConsumer api.LogConsumer
+	// Layered on top of Yaml in order, the later ones win
+	Overrides []*Yaml
+	// Files layered on top of the overrides in order once every option is set
+	ComposeFiles []string
+	Profiles     []string
+	LogConsumer  api.LogConsumer
+}
+
+// Describes a compose service along with the state of its container if one exists
+type ServiceStatus struct {
+	Service  string
+	Profiles []string
+	// Whether the service is enabled by the active profiles
+	Enabled      bool
+	Container    string
+	State        string
+	Health       string
+	StartedAt    time.Time
+	RestartCount int
+	Ports        []string
+}
+
+// Reports if the service is enabled but has no container
+func (s *ServiceStatus) IsMissing() bool {
+	return s.Enabled && s.Container == ""
+}
+
+// Returns how long the container has been running or 0 if it is not running
+func (s *ServiceStatus) Uptime() time.Duration {
+	if s.State != "running" || s.StartedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.StartedAt)
 }
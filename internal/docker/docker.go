@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"github.com/docker/docker/api/types"
 	. "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
 type Client struct {
@@ -39,8 +41,24 @@ func (c *Client) CreateVolume(ctx context.Context, volume *Volume) error {
 	return nil
 }
 
-func (c *Client) GetContainerStats(ctx context.Context, container *Container) error {
+// Reports whether the volume exists
+func (c *Client) VolumeExists(ctx context.Context, volume *Volume) (bool, error) {
+	_, err := c.wrapped.VolumeInspect(ctx, volume.options.Name)
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *Client) RemoveVolume(ctx context.Context, volume *Volume, force bool) error {
+	return c.wrapped.VolumeRemove(ctx, volume.options.Name, force)
+}
 
+// Streams the container stats to the stats response writer until the context is canceled
+func (c *Client) GetContainerStats(ctx context.Context, container *Container) error {
 	res, err := c.wrapped.ContainerStats(ctx, container.Name, true)
 	if err != nil {
 		return err
@@ -58,6 +76,20 @@ func (c *Client) RemoveContainer(ctx context.Context, container *Container, forc
 		Force:         force,
 	})
 }
+
+// Returns everything the container has written to stdout and stderr so far
+func (c *Client) ContainerOutput(ctx context.Context, container *Container) (stdout, stderr string, err error) {
+	res, err := c.wrapped.ContainerLogs(ctx, container.Name, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Close()
+	outBuf, errBuf := &bytes.Buffer{}, &bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(outBuf, errBuf, res); err != nil {
+		return "", "", err
+	}
+	return outBuf.String(), errBuf.String(), nil
+}
 func (c *Client) UnpauseContainer(ctx context.Context, container *Container) error {
 	return c.wrapped.ContainerUnpause(ctx, container.Name)
 }
@@ -74,6 +106,20 @@ func (c *Client) StopContainer(ctx context.Context, container *Container) error
 func (c *Client) StartContainer(ctx context.Context, container *Container) error {
 	return c.wrapped.ContainerStart(ctx, container.Name, types.ContainerStartOptions{})
 }
+
+// Blocks until the container stops and returns its exit code
+func (c *Client) WaitContainer(ctx context.Context, container *Container) (int64, error) {
+	statusCh, errCh := c.wrapped.ContainerWait(ctx, container.Name, WaitConditionNotRunning)
+	select {
+	case status := <-statusCh:
+		if status.Error != nil {
+			return status.StatusCode, errors.New(status.Error.Message)
+		}
+		return status.StatusCode, nil
+	case err := <-errCh:
+		return -1, err
+	}
+}
 func (c *Client) CreateContainer(ctx context.Context, container *Container) error {
 	res, err := c.wrapped.ContainerCreate(
 		ctx,
@@ -0,0 +1,171 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/isolateminds/go-conduit-cli/internal/docker/execopt"
+	"github.com/moby/term"
+)
+
+// Exec represents a command to be executed inside of a running container.
+type Exec struct {
+	Id        string
+	container *Container
+	options   *types.ExecConfig
+}
+
+// SetOptions configures options for the exec.
+// Use this method to set various exec options using functions from the execopt package.
+func (e *Exec) SetOptions(setEOFns ...execopt.SetExecOptFn) {
+	for _, set := range setEOFns {
+		set(e.options)
+	}
+}
+
+// NewExec creates a new Exec instance that runs the command inside of the container.
+func (*Client) NewExec(container *Container, cmd ...string) *Exec {
+	return &Exec{
+		container: container,
+		options:   &types.ExecConfig{Cmd: cmd},
+	}
+}
+
+// Creates the exec instance on the daemon without starting it
+func (c *Client) CreateExec(ctx context.Context, exec *Exec) error {
+	res, err := c.wrapped.ContainerExecCreate(ctx, exec.container.Name, *exec.options)
+	if err != nil {
+		return err
+	}
+	exec.Id = res.ID
+	return nil
+}
+
+// Starts the exec and attaches to its streams, the caller must close the response
+func (c *Client) AttachExec(ctx context.Context, exec *Exec) (types.HijackedResponse, error) {
+	return c.wrapped.ContainerExecAttach(ctx, exec.Id, types.ExecStartCheck{
+		Tty:         exec.options.Tty,
+		ConsoleSize: exec.options.ConsoleSize,
+	})
+}
+
+// Resizes the pseudo-TTY of the exec
+func (c *Client) ResizeExec(ctx context.Context, exec *Exec, height, width uint) error {
+	return c.wrapped.ContainerExecResize(ctx, exec.Id, types.ResizeOptions{
+		Height: height,
+		Width:  width,
+	})
+}
+
+// Waits for the exec to finish and returns its exit code
+func (c *Client) WaitExec(ctx context.Context, exec *Exec) (int, error) {
+	for {
+		res, err := c.wrapped.ContainerExecInspect(ctx, exec.Id)
+		if err != nil {
+			return -1, err
+		}
+		if !res.Running {
+			return res.ExitCode, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+/*
+Creates, starts and attaches to the exec forwarding the standard streams then returns its exit code.
+If a TTY was requested and stdin is a terminal it is put in raw mode and window resizes are propagated.
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.TTY(),
+		execopt.AttachStdin(),
+		execopt.AttachStdout(),
+		execopt.AttachStderr(),
+	)
+	code, err := client.RunExec(ctx, exec, os.Stdin, os.Stdout, os.Stderr)
+*/
+func (c *Client) RunExec(ctx context.Context, exec *Exec, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	if exec.options.Tty {
+		if fd, ok := terminalFd(stdout); ok {
+			if size, err := term.GetWinsize(fd); err == nil {
+				exec.options.ConsoleSize = &[2]uint{uint(size.Height), uint(size.Width)}
+			}
+		}
+	}
+	if err := c.CreateExec(ctx, exec); err != nil {
+		return -1, err
+	}
+	res, err := c.AttachExec(ctx, exec)
+	if err != nil {
+		return -1, err
+	}
+	defer res.Close()
+
+	if exec.options.Tty {
+		if fd, ok := terminalFd(stdin); ok {
+			state, err := term.SetRawTerminal(fd)
+			if err != nil {
+				return -1, err
+			}
+			defer term.RestoreTerminal(fd, state)
+		}
+		if fd, ok := terminalFd(stdout); ok {
+			monitorCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go c.monitorExecSize(monitorCtx, exec, fd)
+		}
+	}
+	if exec.options.AttachStdin && stdin != nil {
+		go func() {
+			io.Copy(res.Conn, stdin)
+			res.CloseWrite()
+		}()
+	}
+
+	outputDone := make(chan error, 1)
+	go func() {
+		if exec.options.Tty {
+			_, err := io.Copy(stdout, res.Reader)
+			outputDone <- err
+			return
+		}
+		_, err := stdcopy.StdCopy(stdout, stderr, res.Reader)
+		outputDone <- err
+	}()
+	select {
+	case err := <-outputDone:
+		if err != nil {
+			return -1, err
+		}
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+	return c.WaitExec(ctx, exec)
+}
+
+// Resizes the exec pseudo-TTY to the current size of the terminal
+func (c *Client) resizeExecToTerminal(ctx context.Context, exec *Exec, fd uintptr) {
+	size, err := term.GetWinsize(fd)
+	if err != nil || size.Height == 0 && size.Width == 0 {
+		return
+	}
+	c.ResizeExec(ctx, exec, uint(size.Height), uint(size.Width))
+}
+
+// Returns the file descriptor if the stream is a terminal
+func terminalFd(stream interface{}) (uintptr, bool) {
+	file, ok := stream.(*os.File)
+	if !ok {
+		return 0, false
+	}
+	fd, isTerminal := term.GetFdInfo(file)
+	return fd, isTerminal
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package docker
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Propagates terminal window size changes to the exec until the context is canceled
+func (c *Client) monitorExecSize(ctx context.Context, exec *Exec, fd uintptr) {
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGWINCH)
+	defer signal.Stop(sigchan)
+	for {
+		select {
+		case <-sigchan:
+			c.resizeExecToTerminal(ctx, exec, fd)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/moby/term"
+)
+
+// Propagates terminal window size changes to the exec until the context is canceled.
+// Windows has no SIGWINCH so the size is polled instead
+func (c *Client) monitorExecSize(ctx context.Context, exec *Exec, fd uintptr) {
+	var height, width uint16
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			size, err := term.GetWinsize(fd)
+			if err != nil {
+				continue
+			}
+			if size.Height != height || size.Width != width {
+				height, width = size.Height, size.Width
+				c.resizeExecToTerminal(ctx, exec, fd)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
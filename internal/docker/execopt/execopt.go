@@ -0,0 +1,134 @@
+package execopt
+
+import (
+	"github.com/docker/docker/api/types"
+)
+
+type SetExecOptFn func(options *types.ExecConfig)
+
+/*
+Sets the user the command runs as
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.User("node"),
+	)
+*/
+func User(user string) SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.User = user
+	}
+}
+
+/*
+Sets the working directory the command runs in
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.WorkingDir("/app"),
+	)
+*/
+func WorkingDir(dir string) SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.WorkingDir = dir
+	}
+}
+
+/*
+Adds environment variables in the form of KEY=VALUE
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.Env("DEBUG=true", "LOG_LEVEL=info"),
+	)
+*/
+func Env(env ...string) SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.Env = append(options.Env, env...)
+	}
+}
+
+/*
+Allocates a pseudo-TTY for the command
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.TTY(),
+	)
+*/
+func TTY() SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.Tty = true
+	}
+}
+
+/*
+Sets the initial size of the pseudo-TTY
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.TTY(),
+		execopt.ConsoleSize(24, 80),
+	)
+*/
+func ConsoleSize(height, width uint) SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.ConsoleSize = &[2]uint{height, width}
+	}
+}
+
+/*
+Keeps stdin open and attaches it to the command
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.AttachStdin(),
+	)
+*/
+func AttachStdin() SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.AttachStdin = true
+	}
+}
+
+/*
+Attaches stdout of the command
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.AttachStdout(),
+	)
+*/
+func AttachStdout() SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.AttachStdout = true
+	}
+}
+
+/*
+Attaches stderr of the command
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.AttachStderr(),
+	)
+*/
+func AttachStderr() SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.AttachStderr = true
+	}
+}
+
+/*
+Runs the command with extended privileges
+
+	exec := client.NewExec(container, "sh")
+	exec.SetOptions(
+		execopt.Privileged(),
+	)
+*/
+func Privileged() SetExecOptFn {
+	return func(options *types.ExecConfig) {
+		options.Privileged = true
+	}
+}
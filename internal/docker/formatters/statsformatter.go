@@ -3,36 +3,44 @@ package formatters
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/isolateminds/go-conduit-cli/internal/docker/response"
 )
 
-type formater struct{ writer io.Writer }
+type formater struct {
+	writer io.Writer
+	mu     sync.Mutex
+	// Holds incomplete json objects until the rest of the stream arrives
+	buf []byte
+}
 
 func (f *formater) Write(p []byte) (n int, err error) {
-	var data response.ContainerStats
-	decoder := json.NewDecoder(bytes.NewReader(p))
-	err = decoder.Decode(&data)
-	if err != nil {
-		return 0, fmt.Errorf("StatsFormatterError:  %s", err)
-	}
-	b, err := json.Marshal(&response.FormatedContainerStats{
-		ID:          data.ID,
-		Name:        data.Name,
-		CpuUsage:    data.FormatCpuUsagePercentage(),
-		MemoryUsage: data.FormatMemoryUsage(),
-		NetworkIO:   data.FormatNetworkIO(),
-		DiskIO:      data.FormatDiskIO(),
-	})
-	if err != nil {
-		return 0, fmt.Errorf("StatsFormatterError:  %s", err)
-	}
-	if n, err = f.writer.Write(b); err != nil {
-		return n, err
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buf = append(f.buf, p...)
+	for {
+		var data response.ContainerStats
+		decoder := json.NewDecoder(bytes.NewReader(f.buf))
+		err = decoder.Decode(&data)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return len(p), nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("StatsFormatterError:  %s", err)
+		}
+		f.buf = f.buf[decoder.InputOffset():]
+		b, err := json.Marshal(data.Format())
+		if err != nil {
+			return 0, fmt.Errorf("StatsFormatterError:  %s", err)
+		}
+		if _, err = f.writer.Write(b); err != nil {
+			return 0, err
+		}
 	}
-	return len(p), nil
 }
 
 // Formats the incoming stats and passes it to the supplied writer
@@ -1,9 +1,10 @@
 package response
 
 import (
-	"encoding/json"
 	"fmt"
 	"math"
+	"strings"
+	"time"
 )
 
 type FormatedContainerStats struct {
@@ -13,127 +14,173 @@ type FormatedContainerStats struct {
 	MemoryUsage string `json:"memoryUsage"`
 	NetworkIO   string `json:"networkIO"`
 	DiskIO      string `json:"diskIO"`
+	PIDs        string `json:"pids"`
+	CpuThrottle string `json:"cpuThrottle"`
 }
+
+// A single blkio entry, op is one of Read, Write, Sync, Async, Discard or Total
+type BlkioStatEntry struct {
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+	Op    string `json:"op"`
+	Value uint64 `json:"value"`
+}
+
+type ThrottlingData struct {
+	Periods          int64 `json:"periods"`
+	ThrottledPeriods int64 `json:"throttled_periods"`
+	ThrottledTime    int64 `json:"throttled_time"`
+}
+
+type CpuStats struct {
+	CpuUsage struct {
+		TotalUsage int64 `json:"total_usage"`
+		// Only populated on cgroup v1
+		PercpuUsage       []int64 `json:"percpu_usage"`
+		UsageInKernelMode int64   `json:"usage_in_kernelmode"`
+		UsageInUserMode   int64   `json:"usage_in_usermode"`
+	} `json:"cpu_usage"`
+	SystemCPUUsage int64          `json:"system_cpu_usage"`
+	OnlineCPUs     int64          `json:"online_cpus"`
+	ThrottlingData ThrottlingData `json:"throttling_data"`
+}
+
+type NetworkStats struct {
+	RxBytes   int64 `json:"rx_bytes"`
+	RxPackets int64 `json:"rx_packets"`
+	RxErrors  int64 `json:"rx_errors"`
+	RxDropped int64 `json:"rx_dropped"`
+	TxBytes   int64 `json:"tx_bytes"`
+	TxPackets int64 `json:"tx_packets"`
+	TxErrors  int64 `json:"tx_errors"`
+	TxDropped int64 `json:"tx_dropped"`
+}
+
 type ContainerStats struct {
 	Name      string `json:"name"`
 	ID        string `json:"id"`
 	Read      string `json:"read"`
 	Preread   string `json:"preread"`
 	PidsStats struct {
-		Current int64       `json:"current"`
-		Limit   json.Number `json:"limit"`
-	} `json:"pidsStats"`
+		Current uint64 `json:"current"`
+		// 0 when there is no limit
+		Limit uint64 `json:"limit"`
+	} `json:"pids_stats"`
 	BlkioStats struct {
-		IoServiceBytesRecursive []interface{} `json:"ioServiceBytesRecursive"`
-		IoServicedRecursive     []interface{} `json:"ioServicedRecursive"`
-		IoQueueRecursive        []interface{} `json:"ioQueueRecursive"`
-		IoServiceTimeRecursive  []interface{} `json:"ioServiceTimeRecursive"`
-		IoWaitTimeRecursive     []interface{} `json:"ioWaitTimeRecursive"`
-		IoMergedRecursive       []interface{} `json:"ioMergedRecursive"`
-		IoTimeRecursive         []interface{} `json:"ioTimeRecursive"`
-		SectorsRecursive        []interface{} `json:"sectorsRecursive"`
-	} `json:"blkioStats"`
-	NumProcs     int64    `json:"numProcs"`
-	StorageStats struct{} `json:"storageStats"`
-	CpuStats     struct {
-		CpuUsage struct {
-			TotalUsage        int64 `json:"totalUsage"`
-			UsageInKernelMode int64 `json:"usageInKernelmode"`
-			UsageInUserMode   int64 `json:"usageInUsermode"`
-		} `json:"cpuUsage"`
-		SystemCPUUsage int64 `json:"systemCpuUsage"`
-		OnlineCPUs     int64 `json:"onlineCpus"`
-		ThrottlingData struct {
-			Periods          int64 `json:"periods"`
-			ThrottledPeriods int64 `json:"throttledPeriods"`
-			ThrottledTime    int64 `json:"throttledTime"`
-		} `json:"throttlingData"`
-	} `json:"cpuStats"`
-	PreCPUStats struct {
-		CpuUsage struct {
-			TotalUsage        int64 `json:"totalUsage"`
-			UsageInKernelMode int64 `json:"usageInKernelmode"`
-			UsageInUserMode   int64 `json:"usageInUsermode"`
-		} `json:"cpuUsage"`
-		SystemCPUUsage int64 `json:"systemCpuUsage"`
-		OnlineCPUs     int64 `json:"onlineCpus"`
-		ThrottlingData struct {
-			Periods          int64 `json:"periods"`
-			ThrottledPeriods int64 `json:"throttledPeriods"`
-			ThrottledTime    int64 `json:"throttledTime"`
-		} `json:"throttlingData"`
-	} `json:"precpuStats"`
-	MemoryStats struct {
+		IoServiceBytesRecursive []BlkioStatEntry `json:"io_service_bytes_recursive"`
+		IoServicedRecursive     []BlkioStatEntry `json:"io_serviced_recursive"`
+		IoQueueRecursive        []BlkioStatEntry `json:"io_queue_recursive"`
+		IoServiceTimeRecursive  []BlkioStatEntry `json:"io_service_time_recursive"`
+		IoWaitTimeRecursive     []BlkioStatEntry `json:"io_wait_time_recursive"`
+		IoMergedRecursive       []BlkioStatEntry `json:"io_merged_recursive"`
+		IoTimeRecursive         []BlkioStatEntry `json:"io_time_recursive"`
+		SectorsRecursive        []BlkioStatEntry `json:"sectors_recursive"`
+	} `json:"blkio_stats"`
+	NumProcs     int64    `json:"num_procs"`
+	StorageStats struct{} `json:"storage_stats"`
+	CpuStats     CpuStats `json:"cpu_stats"`
+	PreCPUStats  CpuStats `json:"precpu_stats"`
+	MemoryStats  struct {
 		Usage int64 `json:"usage"`
-		Stats struct {
-			ActiveAnon          int64 `json:"activeAnon"`
-			ActiveFile          int64 `json:"activeFile"`
-			Anon                int64 `json:"anon"`
-			AnonTHP             int64 `json:"anonTHP"`
-			File                int64 `json:"file"`
-			FileDirty           int64 `json:"fileDirty"`
-			FileMapped          int64 `json:"fileMapped"`
-			FileWriteBack       int64 `json:"fileWriteback"`
-			InactiveAnon        int64 `json:"inactiveAnon"`
-			InactiveFile        int64 `json:"inactiveFile"`
-			KernelStack         int64 `json:"kernelStack"`
-			PgActivate          int64 `json:"pgActivate"`
-			PgDeactivate        int64 `json:"pgDeactivate"`
-			PgFault             int64 `json:"pgFault"`
-			PgLazyFree          int64 `json:"pgLazyFree"`
-			PgLazyFreed         int64 `json:"pgLazyFreed"`
-			PgMajFault          int64 `json:"pgMajFault"`
-			PgRefill            int64 `json:"pgRefill"`
-			PgScan              int64 `json:"pgScan"`
-			PgSteal             int64 `json:"pgSteal"`
-			Shmem               int64 `json:"shmem"`
-			Slab                int64 `json:"slab"`
-			SlabReclaimable     int64 `json:"slabReclaimable"`
-			SlabUnreclaimable   int64 `json:"slabUnreclaimable"`
-			Sock                int64 `json:"sock"`
-			ThpCollapseAlloc    int64 `json:"thpCollapseAlloc"`
-			ThpFaultAlloc       int64 `json:"thpFaultAlloc"`
-			Unevictable         int64 `json:"unevictable"`
-			WorkingSetActivate  int64 `json:"workingsetActivate"`
-			WorkingSetNoReclaim int64 `json:"workingsetNodereclaim"`
-			WorkingSetRefault   int64 `json:"workingsetRefault"`
-		} `json:"stats"`
-		Limit int64 `json:"limit"`
-	} `json:"memoryStats"`
-	Networks struct {
-		Eth0 struct {
-			RxBytes   int64 `json:"rxBytes"`
-			RxPackets int64 `json:"rxPackets"`
-			RxErrors  int64 `json:"rxErrors"`
-			RxDropped int64 `json:"rxDropped"`
-			TxBytes   int64 `json:"txBytes"`
-			TxPackets int64 `json:"txPackets"`
-			TxErrors  int64 `json:"txErrors"`
-			TxDropped int64 `json:"txDropped"`
-		} `json:"eth0"`
-	} `json:"networks"`
+		// The keys differ between cgroup v1 (eg: total_inactive_file) and v2 (eg: inactive_file)
+		Stats map[string]int64 `json:"stats"`
+		Limit int64            `json:"limit"`
+	} `json:"memory_stats"`
+	// Keyed by interface name eg: eth0
+	Networks map[string]NetworkStats `json:"networks"`
 }
 
-func (stats *ContainerStats) FormatCpuUsagePercentage() string {
+// Returns the CPU usage percentage of the container since the previous read
+func (stats *ContainerStats) CpuUsagePercentage() float64 {
 	// Calculate the total CPU time used by the container
 	totalCPUUsage := float64(stats.CpuStats.CpuUsage.TotalUsage - stats.PreCPUStats.CpuUsage.TotalUsage)
 
 	// Calculate the system CPU time
 	systemCPUUsage := float64(stats.CpuStats.SystemCPUUsage - stats.PreCPUStats.SystemCPUUsage)
 
-	// Calculate the number of online CPUs
+	// Calculate the number of online CPUs, older daemons only report the per cpu usage
 	onlineCPUs := float64(stats.CpuStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CpuStats.CpuUsage.PercpuUsage))
+	}
 
+	if totalCPUUsage <= 0 || systemCPUUsage <= 0 {
+		return 0
+	}
 	// Calculate the CPU usage percentage
 	cpuUsagePercentage := (totalCPUUsage / systemCPUUsage) * onlineCPUs * 100.0
 	if math.IsNaN(cpuUsagePercentage) {
-		return "0.00%"
+		return 0
+	}
+	return cpuUsagePercentage
+}
+
+// Returns the throttled periods and time since the previous read
+func (stats *ContainerStats) CpuThrottling() (throttledPeriods int64, throttledTime time.Duration) {
+	current := stats.CpuStats.ThrottlingData
+	previous := stats.PreCPUStats.ThrottlingData
+	return current.ThrottledPeriods - previous.ThrottledPeriods, time.Duration(current.ThrottledTime - previous.ThrottledTime)
+}
+
+/*
+Returns the memory usage without the inactive file cache the same way the docker cli does.
+On cgroup v1 total_inactive_file is subtracted and on cgroup v2 inactive_file
+*/
+func (stats *ContainerStats) MemoryUsage() int64 {
+	usage := stats.MemoryStats.Usage
+	if v, ok := stats.MemoryStats.Stats["total_inactive_file"]; ok {
+		if v < usage {
+			return usage - v
+		}
+		return usage
+	}
+	if v := stats.MemoryStats.Stats["inactive_file"]; v < usage {
+		return usage - v
+	}
+	return usage
+}
+
+// Returns the memory usage percentage of the memory limit
+func (stats *ContainerStats) MemoryPercentage() float64 {
+	if stats.MemoryStats.Limit == 0 {
+		return 0
 	}
-	return fmt.Sprintf("%.2f%%", cpuUsagePercentage)
+	return float64(stats.MemoryUsage()) / float64(stats.MemoryStats.Limit) * 100.0
+}
+
+// Returns the current number of pids and the limit, 0 when there is no limit
+func (stats *ContainerStats) Pids() (current, limit uint64) {
+	return stats.PidsStats.Current, stats.PidsStats.Limit
+}
+
+// Returns the total received and transmitted network bytes summed across all interfaces
+func (stats *ContainerStats) NetworkIO() (rx, tx int64) {
+	for _, network := range stats.Networks {
+		rx += network.RxBytes
+		tx += network.TxBytes
+	}
+	return rx, tx
+}
+
+// Returns the total read and written block device bytes summed across all devices
+func (stats *ContainerStats) DiskIO() (read, write int64) {
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += int64(entry.Value)
+		case "write":
+			write += int64(entry.Value)
+		}
+	}
+	return read, write
+}
+
+func (stats *ContainerStats) FormatCpuUsagePercentage() string {
+	return fmt.Sprintf("%.2f%%", stats.CpuUsagePercentage())
 }
 func (stats *ContainerStats) FormatMemoryUsage() string {
 	// Get the memory usage and limit in bytes
-	memoryUsage := stats.MemoryStats.Usage
+	memoryUsage := stats.MemoryUsage()
 	memoryLimit := stats.MemoryStats.Limit
 
 	// Convert the memory usage and limit to human-readable strings
@@ -143,10 +190,20 @@ func (stats *ContainerStats) FormatMemoryUsage() string {
 	// Combine the strings and return the result
 	return fmt.Sprintf("%s / %s", memoryUsageStr, memoryLimitStr)
 }
+func (stats *ContainerStats) FormatPids() string {
+	current, limit := stats.Pids()
+	if limit == 0 {
+		return fmt.Sprintf("%d", current)
+	}
+	return fmt.Sprintf("%d / %d", current, limit)
+}
+func (stats *ContainerStats) FormatCpuThrottling() string {
+	periods, throttled := stats.CpuThrottling()
+	return fmt.Sprintf("%d / %s", periods, throttled)
+}
 func (stats *ContainerStats) FormatNetworkIO() string {
 	// Get the network I/O values in bytes
-	rxBytes := stats.Networks.Eth0.RxBytes
-	txBytes := stats.Networks.Eth0.TxBytes
+	rxBytes, txBytes := stats.NetworkIO()
 
 	// Convert the network I/O values to human-readable strings
 	rxBytesStr := bytesToHumanReadable(rxBytes)
@@ -157,17 +214,8 @@ func (stats *ContainerStats) FormatNetworkIO() string {
 }
 func (stats *ContainerStats) FormatDiskIO() string {
 	// Get the disk read/write values in bytes
-	readBytes := int64(0)
-	writeBytes := int64(0)
+	readBytes, writeBytes := stats.DiskIO()
 
-	if len(stats.BlkioStats.IoServiceBytesRecursive) >= 2 {
-		if readVal, ok := stats.BlkioStats.IoServiceBytesRecursive[0].(float64); ok {
-			readBytes = int64(readVal)
-		}
-		if writeVal, ok := stats.BlkioStats.IoServiceBytesRecursive[1].(float64); ok {
-			writeBytes = int64(writeVal)
-		}
-	}
 	// Convert the disk read/write values to human-readable strings
 	readBytesStr := bytesToHumanReadable(readBytes)
 	writeBytesStr := bytesToHumanReadable(writeBytes)
@@ -175,6 +223,20 @@ func (stats *ContainerStats) FormatDiskIO() string {
 	// Combine the strings and return the result
 	return fmt.Sprintf("%s / %s", readBytesStr, writeBytesStr)
 }
+
+// Formats the stats into human readable strings
+func (stats *ContainerStats) Format() *FormatedContainerStats {
+	return &FormatedContainerStats{
+		ID:          stats.ID,
+		Name:        strings.TrimPrefix(stats.Name, "/"),
+		CpuUsage:    stats.FormatCpuUsagePercentage(),
+		MemoryUsage: stats.FormatMemoryUsage(),
+		NetworkIO:   stats.FormatNetworkIO(),
+		DiskIO:      stats.FormatDiskIO(),
+		PIDs:        stats.FormatPids(),
+		CpuThrottle: stats.FormatCpuThrottling(),
+	}
+}
 func bytesToHumanReadable(bytes int64) string {
 	// Define the units and their corresponding values in bytes
 	units := []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
@@ -0,0 +1,127 @@
+package response
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Reads a docker stats payload recorded from the /containers/{id}/stats endpoint
+func loadStats(t *testing.T, name string) *ContainerStats {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := &ContainerStats{}
+	if err := json.Unmarshal(b, stats); err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func TestContainerStats(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		memoryUsage   int64
+		cpuPercentage float64
+		rx, tx        int64
+		read, write   int64
+		pids, limit   uint64
+		periods       int64
+		throttled     time.Duration
+		formatted     FormatedContainerStats
+	}{
+		{
+			// total_inactive_file is subtracted instead of inactive_file
+			// and the cpus are counted from percpu_usage
+			fixture:       "stats_cgroupv1.json",
+			memoryUsage:   157286400 - 41943040,
+			cpuPercentage: 10,
+			rx:            5242880,
+			tx:            1048576,
+			read:          10485760 + 1048576,
+			write:         2097152 + 4096,
+			pids:          23,
+			limit:         0,
+			periods:       3,
+			throttled:     250 * time.Millisecond,
+			formatted: FormatedContainerStats{
+				Name:        "conduit",
+				CpuUsage:    "10.00%",
+				MemoryUsage: "110.00 MB / 2.00 GB",
+				NetworkIO:   "5.00 MB / 1.00 MB",
+				DiskIO:      "11.00 MB / 2.00 MB",
+				PIDs:        "23",
+				CpuThrottle: "3 / 250ms",
+			},
+		},
+		{
+			// inactive_file is subtracted, the blkio ops are lowercase and there are two networks
+			fixture:       "stats_cgroupv2.json",
+			memoryUsage:   268435456 - 83886080,
+			cpuPercentage: 50,
+			rx:            3145728 + 1048576,
+			tx:            2097152 + 524288,
+			read:          8388608 + 4194304,
+			write:         16777216,
+			pids:          41,
+			limit:         4096,
+			periods:       2,
+			throttled:     250 * time.Millisecond,
+			formatted: FormatedContainerStats{
+				Name:        "conduit-database",
+				CpuUsage:    "50.00%",
+				MemoryUsage: "176.00 MB / 1.00 GB",
+				NetworkIO:   "4.00 MB / 2.50 MB",
+				DiskIO:      "12.00 MB / 16.00 MB",
+				PIDs:        "41 / 4096",
+				CpuThrottle: "2 / 250ms",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			stats := loadStats(t, test.fixture)
+			if got := stats.MemoryUsage(); got != test.memoryUsage {
+				t.Errorf("MemoryUsage() = %d want %d", got, test.memoryUsage)
+			}
+			if got := stats.CpuUsagePercentage(); math.Abs(got-test.cpuPercentage) > 0.001 {
+				t.Errorf("CpuUsagePercentage() = %f want %f", got, test.cpuPercentage)
+			}
+			if rx, tx := stats.NetworkIO(); rx != test.rx || tx != test.tx {
+				t.Errorf("NetworkIO() = %d, %d want %d, %d", rx, tx, test.rx, test.tx)
+			}
+			if read, write := stats.DiskIO(); read != test.read || write != test.write {
+				t.Errorf("DiskIO() = %d, %d want %d, %d", read, write, test.read, test.write)
+			}
+			if pids, limit := stats.Pids(); pids != test.pids || limit != test.limit {
+				t.Errorf("Pids() = %d, %d want %d, %d", pids, limit, test.pids, test.limit)
+			}
+			if periods, throttled := stats.CpuThrottling(); periods != test.periods || throttled != test.throttled {
+				t.Errorf("CpuThrottling() = %d, %s want %d, %s", periods, throttled, test.periods, test.throttled)
+			}
+			formatted := stats.Format()
+			test.formatted.ID = stats.ID
+			if *formatted != test.formatted {
+				t.Errorf("Format() = %+v want %+v", *formatted, test.formatted)
+			}
+		})
+	}
+}
+
+func TestMemoryUsageWithoutCacheStats(t *testing.T) {
+	stats := &ContainerStats{}
+	stats.MemoryStats.Usage = 1024
+	if got := stats.MemoryUsage(); got != 1024 {
+		t.Errorf("MemoryUsage() = %d want 1024", got)
+	}
+	//the cache can not be larger than the usage
+	stats.MemoryStats.Stats = map[string]int64{"total_inactive_file": 4096}
+	if got := stats.MemoryUsage(); got != 1024 {
+		t.Errorf("MemoryUsage() = %d want 1024", got)
+	}
+}
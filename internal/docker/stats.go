@@ -0,0 +1,113 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/internal/docker/response"
+)
+
+// StatsSample is a single decoded stats reading of a container along with
+// the changes since the previous reading of the same container.
+type StatsSample struct {
+	Container string
+	Stats     *response.ContainerStats
+	Read      time.Time
+	// Time elapsed since the previous sample, 0 for the first one
+	Interval        time.Duration
+	CPUPercentage   float64
+	NetworkRxDelta  int64
+	NetworkTxDelta  int64
+	BlockReadDelta  int64
+	BlockWriteDelta int64
+}
+
+/*
+Streams the stats of all the containers concurrently and fans them in to a single channel.
+Both channels are closed once every stream has ended, cancel the context to stop streaming.
+
+	samples, errs := client.StreamStats(ctx, client.NewContainer("conduit"))
+	for sample := range samples {
+		fmt.Println(sample.Container, sample.CPUPercentage)
+	}
+	for err := range errs {
+		...
+	}
+*/
+func (c *Client) StreamStats(ctx context.Context, containers ...*Container) (<-chan StatsSample, <-chan error) {
+	samples := make(chan StatsSample)
+	errs := make(chan error, len(containers))
+	var wg sync.WaitGroup
+	for _, container := range containers {
+		wg.Add(1)
+		go func(container *Container) {
+			defer wg.Done()
+			if err := c.streamContainerStats(ctx, container, samples); err != nil && ctx.Err() == nil {
+				errs <- fmt.Errorf("StreamStatsError: %s: %s", container.Name, err)
+			}
+		}(container)
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+		close(errs)
+	}()
+	return samples, errs
+}
+
+// Decodes the stats stream of a single container and sends the samples until the stream ends
+func (c *Client) streamContainerStats(ctx context.Context, container *Container, samples chan<- StatsSample) error {
+	res, err := c.wrapped.ContainerStats(ctx, container.Name, true)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	decoder := json.NewDecoder(res.Body)
+	var previous *StatsSample
+	for {
+		stats := &response.ContainerStats{}
+		if err := decoder.Decode(stats); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		sample := newStatsSample(container.Name, stats, previous)
+		select {
+		case samples <- sample:
+		case <-ctx.Done():
+			return nil
+		}
+		previous = &sample
+	}
+}
+
+func newStatsSample(name string, stats *response.ContainerStats, previous *StatsSample) StatsSample {
+	read, _ := time.Parse(time.RFC3339Nano, stats.Read)
+	sample := StatsSample{
+		Container:     name,
+		Stats:         stats,
+		Read:          read,
+		CPUPercentage: stats.CpuUsagePercentage(),
+	}
+	if previous == nil {
+		return sample
+	}
+	rx, tx := stats.NetworkIO()
+	prevRx, prevTx := previous.Stats.NetworkIO()
+	readBytes, writeBytes := stats.DiskIO()
+	prevReadBytes, prevWriteBytes := previous.Stats.DiskIO()
+
+	sample.Interval = sample.Read.Sub(previous.Read)
+	sample.NetworkRxDelta = rx - prevRx
+	sample.NetworkTxDelta = tx - prevTx
+	sample.BlockReadDelta = readBytes - prevReadBytes
+	sample.BlockWriteDelta = writeBytes - prevWriteBytes
+	return sample
+}
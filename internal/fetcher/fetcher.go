@@ -0,0 +1,215 @@
+// Package fetcher downloads templates over http with timeouts, retries, an ETag revalidated
+// on disk cache and optional SHA-256 verification.
+package fetcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Responses larger than this are rejected, templates are only a few KB
+const maxBodySize = 10 << 20
+
+// Returned wrapped when the server responds with 404 so callers can fall back to another file
+var ErrNotFound = errors.New("404 Not Found")
+
+type Fetcher struct {
+	client   *http.Client
+	retries  int
+	backoff  time.Duration
+	cacheDir string
+	manifest Manifest
+}
+
+type SetFetcherOptions func(f *Fetcher)
+
+// Sets the timeout of each attempt
+func WithTimeout(timeout time.Duration) SetFetcherOptions {
+	return func(f *Fetcher) {
+		f.client.Timeout = timeout
+	}
+}
+
+// Sets how many times a failed request is retried, the wait doubles after every attempt
+func WithRetries(retries int, backoff time.Duration) SetFetcherOptions {
+	return func(f *Fetcher) {
+		f.retries = retries
+		f.backoff = backoff
+	}
+}
+
+// Sets the cache directory, an empty dir disables caching
+func WithCacheDir(dir string) SetFetcherOptions {
+	return func(f *Fetcher) {
+		f.cacheDir = dir
+	}
+}
+
+// Verifies every response against the manifest, files missing from the manifest are rejected
+func WithManifest(manifest Manifest) SetFetcherOptions {
+	return func(f *Fetcher) {
+		f.manifest = manifest
+	}
+}
+
+/*
+Returns a fetcher with a 15s timeout, 3 retries starting at 500ms, proxy settings from the
+environment (HTTP_PROXY, HTTPS_PROXY and NO_PROXY) and caching under the user cache dir
+
+	f := fetcher.NewFetcher(fetcher.WithRetries(5, time.Second))
+	b, err := f.Fetch(ctx, "https://example.com/docker-compose.yml")
+*/
+func NewFetcher(options ...SetFetcherOptions) *Fetcher {
+	f := &Fetcher{
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+		},
+		retries:  3,
+		backoff:  500 * time.Millisecond,
+		cacheDir: defaultCacheDir(),
+	}
+	for _, option := range options {
+		option(f)
+	}
+	return f
+}
+
+// Fetches the url with a default fetcher
+func Fetch(ctx context.Context, url string) ([]byte, error) {
+	return NewFetcher().Fetch(ctx, url)
+}
+
+/*
+Fetches the url retrying network errors, 429 and 5xx responses. Any other non 2xx response is an error.
+A cached copy is revalidated with its ETag and used as is when the server can not be reached
+*/
+func (f *Fetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	cached, etag := f.readCache(url)
+	var lastErr error
+	for attempt := 0; attempt <= f.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(f.backoff << (attempt - 1)):
+			}
+		}
+		b, newETag, retry, err := f.do(ctx, url, etag, cached)
+		if err == nil {
+			if err := f.verify(url, b); err != nil {
+				return nil, err
+			}
+			f.writeCache(url, b, newETag)
+			return b, nil
+		}
+		lastErr = err
+		if !retry {
+			return nil, err
+		}
+	}
+	if cached != nil {
+		if err := f.verify(url, cached); err == nil {
+			return cached, nil
+		}
+	}
+	return nil, fmt.Errorf("GET %s failed after %d attempts: %s", url, f.retries+1, lastErr)
+}
+
+// Does a single attempt and reports whether it is worth retrying on error
+func (f *Fetcher) do(ctx context.Context, url, etag string, cached []byte) (b []byte, newETag string, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if etag != "" && cached != nil {
+		req.Header.Set("If-None-Match", etag)
+	}
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached != nil:
+		return cached, etag, false, nil
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return nil, "", true, fmt.Errorf("GET %s: %s", url, res.Status)
+	case res.StatusCode == http.StatusNotFound:
+		return nil, "", false, fmt.Errorf("GET %s: %w", url, ErrNotFound)
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return nil, "", false, fmt.Errorf("GET %s: %s", url, res.Status)
+	}
+	b, err = io.ReadAll(io.LimitReader(res.Body, maxBodySize+1))
+	if err != nil {
+		return nil, "", true, err
+	}
+	if len(b) > maxBodySize {
+		return nil, "", false, fmt.Errorf("GET %s: response is larger than %d bytes", url, maxBodySize)
+	}
+	return b, res.Header.Get("ETag"), false, nil
+}
+
+func (f *Fetcher) verify(url string, b []byte) error {
+	if f.manifest == nil {
+		return nil
+	}
+	return f.manifest.Verify(path.Base(url), b)
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "goconduit", "templates")
+}
+
+// The cache file of the url, the etag is stored next to it with the .etag extension
+func (f *Fetcher) cachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(f.cacheDir, hex.EncodeToString(sum[:]))
+}
+
+func (f *Fetcher) readCache(url string) (b []byte, etag string) {
+	if f.cacheDir == "" {
+		return nil, ""
+	}
+	b, err := os.ReadFile(f.cachePath(url))
+	if err != nil {
+		return nil, ""
+	}
+	e, _ := os.ReadFile(f.cachePath(url) + ".etag")
+	return b, string(e)
+}
+
+// Caching is best effort, failing to write the cache never fails the fetch
+func (f *Fetcher) writeCache(url string, b []byte, etag string) {
+	if f.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(f.cacheDir, fs.ModePerm); err != nil {
+		return
+	}
+	if err := os.WriteFile(f.cachePath(url), b, 0o644); err != nil {
+		return
+	}
+	if etag == "" {
+		os.Remove(f.cachePath(url) + ".etag")
+		return
+	}
+	os.WriteFile(f.cachePath(url)+".etag", []byte(etag), 0o644)
+}
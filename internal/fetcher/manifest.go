@@ -0,0 +1,67 @@
+package fetcher
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// SHA-256 checksums keyed by file name
+type Manifest map[string]string
+
+/*
+Parses a manifest in the sha256sum format
+
+	e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  docker-compose.yml
+	d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592 *conduit.env
+*/
+func ParseManifest(b []byte) (Manifest, error) {
+	manifest := Manifest{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid manifest line %d: %q", line, text)
+		}
+		sum := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid sha256 on manifest line %d: %q", line, fields[0])
+		}
+		//sha256sum marks binary mode with a leading *
+		manifest[strings.TrimPrefix(fields[1], "*")] = sum
+	}
+	return manifest, scanner.Err()
+}
+
+// Reads and parses a manifest file
+func ReadManifest(src string) (Manifest, error) {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(b)
+}
+
+// Returns an error if the file is not in the manifest or its checksum differs
+func (m Manifest) Verify(name string, b []byte) error {
+	expected, ok := m[name]
+	if !ok {
+		return fmt.Errorf("%s is not in the checksum manifest", name)
+	}
+	sum := sha256.Sum256(b)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("%w for %s: expected %s got %s", ErrChecksumMismatch, name, expected, actual)
+	}
+	return nil
+}
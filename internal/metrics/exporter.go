@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/internal/docker"
+)
+
+// Samples older than this are no longer exported, eg: the container was stopped
+const staleAfter = 30 * time.Second
+
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	value func(s docker.StatsSample) float64
+}
+
+var containerMetrics = []metric{
+	{
+		name: "conduit_container_cpu_usage_percent",
+		help: "CPU usage percentage of the container across all cores",
+		kind: "gauge",
+		value: func(s docker.StatsSample) float64 {
+			return s.CPUPercentage
+		},
+	},
+	{
+		name: "conduit_container_memory_usage_bytes",
+		help: "Memory usage of the container without the inactive file cache",
+		kind: "gauge",
+		value: func(s docker.StatsSample) float64 {
+			return float64(s.Stats.MemoryUsage())
+		},
+	},
+	{
+		name: "conduit_container_memory_limit_bytes",
+		help: "Memory limit of the container",
+		kind: "gauge",
+		value: func(s docker.StatsSample) float64 {
+			return float64(s.Stats.MemoryStats.Limit)
+		},
+	},
+	{
+		name: "conduit_container_network_receive_bytes_total",
+		help: "Bytes received by the container across all network interfaces",
+		kind: "counter",
+		value: func(s docker.StatsSample) float64 {
+			rx, _ := s.Stats.NetworkIO()
+			return float64(rx)
+		},
+	},
+	{
+		name: "conduit_container_network_transmit_bytes_total",
+		help: "Bytes transmitted by the container across all network interfaces",
+		kind: "counter",
+		value: func(s docker.StatsSample) float64 {
+			_, tx := s.Stats.NetworkIO()
+			return float64(tx)
+		},
+	},
+	{
+		name: "conduit_container_block_read_bytes_total",
+		help: "Bytes read by the container from block devices",
+		kind: "counter",
+		value: func(s docker.StatsSample) float64 {
+			read, _ := s.Stats.DiskIO()
+			return float64(read)
+		},
+	},
+	{
+		name: "conduit_container_block_write_bytes_total",
+		help: "Bytes written by the container to block devices",
+		kind: "counter",
+		value: func(s docker.StatsSample) float64 {
+			_, write := s.Stats.DiskIO()
+			return float64(write)
+		},
+	},
+	{
+		name: "conduit_container_pids",
+		help: "Number of processes running in the container",
+		kind: "gauge",
+		value: func(s docker.StatsSample) float64 {
+			current, _ := s.Stats.Pids()
+			return float64(current)
+		},
+	},
+}
+
+type entry struct {
+	service  string
+	sample   docker.StatsSample
+	received time.Time
+}
+
+// Exporter keeps the latest stats sample of each container and serves them
+// in the prometheus text exposition format.
+type Exporter struct {
+	project string
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+/*
+Consumes the samples until the channel is closed, services maps container names
+to their compose service names and is used for labelling
+*/
+func (e *Exporter) Consume(samples <-chan docker.StatsSample, services map[string]string) {
+	for sample := range samples {
+		e.mu.Lock()
+		e.entries[sample.Container] = entry{
+			service:  services[sample.Container],
+			sample:   sample,
+			received: time.Now(),
+		}
+		e.mu.Unlock()
+	}
+}
+
+// Implements http.Handler
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := e.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Writes the metrics in the prometheus text exposition format
+func (e *Exporter) Write(w io.Writer) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	containers := []string{}
+	for name, entry := range e.entries {
+		if time.Since(entry.received) < staleAfter {
+			containers = append(containers, name)
+		}
+	}
+	sort.Strings(containers)
+
+	for _, m := range containerMetrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind); err != nil {
+			return err
+		}
+		for _, name := range containers {
+			entry := e.entries[name]
+			_, err := fmt.Fprintf(w, "%s{project=\"%s\",service=\"%s\",container=\"%s\"} %g\n",
+				m.name,
+				escapeLabel(e.project),
+				escapeLabel(entry.service),
+				escapeLabel(name),
+				m.value(entry.sample),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Creates an exporter whose metrics are labelled with the compose project name
+func NewExporter(project string) *Exporter {
+	return &Exporter{
+		project: project,
+		entries: map[string]entry{},
+	}
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
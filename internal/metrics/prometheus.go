@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Adds a static scrape job for the target to the prometheus config file at src.
+Returns false without modifying the file if a job with the same name already exists.
+The file is edited as a node tree so comments and the order of the keys are kept
+*/
+func AddScrapeTarget(src, job, target string) (added bool, err error) {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return false, err
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return false, err
+	}
+	if len(doc.Content) == 0 {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return false, errors.New("the prometheus config is not a mapping")
+	}
+	scrapeConfigs := mappingValue(root, "scrape_configs")
+	switch {
+	case scrapeConfigs == nil:
+		scrapeConfigs = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, scalar("scrape_configs"), scrapeConfigs)
+	case scrapeConfigs.Kind == yaml.ScalarNode && scrapeConfigs.Tag == "!!null":
+		scrapeConfigs.Kind, scrapeConfigs.Tag, scrapeConfigs.Value = yaml.SequenceNode, "!!seq", ""
+	case scrapeConfigs.Kind != yaml.SequenceNode:
+		return false, errors.New("scrape_configs is not a list")
+	}
+	for _, sc := range scrapeConfigs.Content {
+		if name := mappingValue(sc, "job_name"); name != nil && name.Value == job {
+			return false, nil
+		}
+	}
+	//a flow style list eg: scrape_configs: [] would put the new job on a single line
+	scrapeConfigs.Style = 0
+	scrapeConfigs.Content = append(scrapeConfigs.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		scalar("job_name"), scalar(job),
+		scalar("metrics_path"), scalar("/metrics"),
+		scalar("scheme"), scalar("http"),
+		scalar("static_configs"), {Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{
+			{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				scalar("targets"), {Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{scalar(target)}},
+			}},
+		}},
+	}})
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return false, err
+	}
+	if err := encoder.Close(); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(src, buf.Bytes(), fs.ModePerm)
+}
+
+// Returns the value of the key in a mapping node or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
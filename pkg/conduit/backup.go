@@ -0,0 +1,348 @@
+package conduit
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Bumped whenever the layout of the backup archive changes
+	backupFormatVersion = 1
+
+	backupMetadataFile = "metadata.json"
+	backupDumpFile     = "database.dump"
+)
+
+// Stored as the first file of every backup archive so restore can check it before touching the database
+type BackupMetadata struct {
+	FormatVersion int       `json:"formatVersion"`
+	ProjectName   string    `json:"projectName"`
+	Database      string    `json:"database"`
+	ImageTag      string    `json:"imageTag"`
+	UIImageTag    string    `json:"uiImageTag"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// The dump and restore commands run inside of the database container, the credentials come from its environment
+var (
+	dumpCommands = map[string]string{
+		"mongodb":  `exec mongodump --quiet --archive --db "$MONGO_INITDB_DATABASE" --username "$MONGO_INITDB_ROOT_USERNAME" --password "$MONGO_INITDB_ROOT_PASSWORD" --authenticationDatabase admin`,
+		"postgres": `exec pg_dump --format=custom --username "$POSTGRES_USER" "$POSTGRES_DB"`,
+	}
+	restoreCommands = map[string]string{
+		"mongodb":  `exec mongorestore --quiet --archive --drop --nsInclude "$MONGO_INITDB_DATABASE.*" --username "$MONGO_INITDB_ROOT_USERNAME" --password "$MONGO_INITDB_ROOT_PASSWORD" --authenticationDatabase admin`,
+		"postgres": `exec pg_restore --clean --if-exists --no-owner --username "$POSTGRES_USER" --dbname "$POSTGRES_DB"`,
+	}
+)
+
+// The layout of the timestamp in backup archive names
+const backupTimestampLayout = "20060102150405"
+
+// Returns the path of a new backup archive in dir, defaults to the backups directory of the project root
+func BackupPath(dir, projectName, database string, at time.Time) string {
+	if dir == "" {
+		dir = backupsDir
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s-%s.tar.gz", projectName, database, at.Format(backupTimestampLayout)))
+}
+
+// How many backup archives are kept, zero values keep everything
+type BackupRetention struct {
+	Keep   int
+	MaxAge time.Duration
+}
+
+/*
+Deletes the backup archives of the project in dir that exceed the retention, newest first.
+Only archives named by BackupPath are considered and the newest one is never deleted. Returns the deleted paths
+*/
+func PruneBackups(dir, projectName, database string, retention BackupRetention, now time.Time) ([]string, error) {
+	if dir == "" {
+		dir = backupsDir
+	}
+	prefix := fmt.Sprintf("%s-%s-", projectName, database)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type archive struct {
+		path string
+		at   time.Time
+	}
+	archives := []archive{}
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok || entry.IsDir() || !strings.HasSuffix(stamp, ".tar.gz") {
+			continue
+		}
+		at, err := time.ParseInLocation(backupTimestampLayout, strings.TrimSuffix(stamp, ".tar.gz"), time.Local)
+		if err != nil {
+			continue
+		}
+		archives = append(archives, archive{path: filepath.Join(dir, name), at: at})
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].at.After(archives[j].at)
+	})
+	deleted := []string{}
+	for i, a := range archives {
+		if i == 0 {
+			continue
+		}
+		tooMany := retention.Keep > 0 && i >= retention.Keep
+		tooOld := retention.MaxAge > 0 && now.Sub(a.at) > retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(a.path); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, a.path)
+	}
+	return deleted, nil
+}
+
+/*
+Takes a backup every time the schedule is due until the context is canceled then prunes the old archives.
+A failed backup is logged and the schedule carries on. Every result is logged as structured records
+
+	{"time":"...","level":"INFO","msg":"backup","file":"backups/conduit-mongodb-20230901120000.tar.gz","bytes":1024,"duration":"2.1s"}
+*/
+func (c *Conduit) RunScheduledBackups(ctx context.Context, schedule Schedule, dir string, retention BackupRetention, logger *slog.Logger) error {
+	logger.Info("scheduler started", "project", c.json.ProjectName, "database", c.json.Database, "dir", dir, "keep", retention.Keep, "maxAge", retention.MaxAge.String())
+	for {
+		next := schedule.Next(time.Now())
+		logger.Info("next backup", "at", next)
+		select {
+		case <-ctx.Done():
+			logger.Info("scheduler stopped")
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+		started := time.Now()
+		dst := BackupPath(dir, c.json.ProjectName, c.json.Database, started)
+		if _, err := c.BackupDatabase(ctx, dst); err != nil {
+			if ctx.Err() != nil {
+				logger.Info("scheduler stopped")
+				return nil
+			}
+			logger.Error("backup failed", "file", dst, "error", err.Error())
+			continue
+		}
+		var size int64
+		if info, err := os.Stat(dst); err == nil {
+			size = info.Size()
+		}
+		logger.Info("backup", "file", dst, "bytes", size, "duration", time.Since(started).Round(time.Millisecond).String())
+		deleted, err := PruneBackups(dir, c.json.ProjectName, c.json.Database, retention, time.Now())
+		for _, file := range deleted {
+			logger.Info("pruned", "file", file)
+		}
+		if err != nil {
+			logger.Error("prune failed", "error", err.Error())
+		}
+	}
+}
+
+/*
+Dumps the database with mongodump or pg_dump inside of the running database container and writes
+a gzipped tar archive to dst holding the metadata and the dump
+*/
+func (c *Conduit) BackupDatabase(ctx context.Context, dst string) (*BackupMetadata, error) {
+	command, ok := dumpCommands[c.json.Database]
+	if !ok {
+		return nil, errors.New("the project has no database")
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), fs.ModePerm); err != nil {
+		return nil, err
+	}
+	imageTag, uiImageTag := c.ImageTags()
+	metadata := &BackupMetadata{
+		FormatVersion: backupFormatVersion,
+		ProjectName:   c.json.ProjectName,
+		Database:      c.json.Database,
+		ImageTag:      imageTag,
+		UIImageTag:    uiImageTag,
+		CreatedAt:     time.Now().UTC(),
+	}
+	//the dump is spooled to disk first because tar needs the size up front
+	dump, err := os.CreateTemp(filepath.Dir(dst), ".dump-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(dump.Name())
+	defer dump.Close()
+
+	stderr := &bytes.Buffer{}
+	code, err := c.ExecStreams(ctx, c.json.Database, &ExecOptions{Cmd: []string{"sh", "-c", command}}, nil, dump, stderr)
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("dumping %s exited with code %d: %s", c.json.Database, code, strings.TrimSpace(stderr.String()))
+	}
+	if _, err := dump.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := writeBackupArchive(dst, metadata, dump); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// Writes the archive to a temporary file first so a failed backup never leaves a truncated archive behind
+func writeBackupArchive(dst string, metadata *BackupMetadata, dump *os.File) (err error) {
+	info, err := dump.Stat()
+	if err != nil {
+		return err
+	}
+	meta, err := json.MarshalIndent(metadata, "", "	")
+	if err != nil {
+		return err
+	}
+	partial := dst + ".partial"
+	out, err := os.Create(partial)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(partial)
+		}
+	}()
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	if err = writeTarFile(tw, backupMetadataFile, int64(len(meta)), metadata.CreatedAt, bytes.NewReader(meta)); err != nil {
+		return err
+	}
+	if err = writeTarFile(tw, backupDumpFile, info.Size(), metadata.CreatedAt, dump); err != nil {
+		return err
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partial, dst)
+}
+
+func writeTarFile(tw *tar.Writer, name string, size int64, modTime time.Time, r io.Reader) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, r, size)
+	return err
+}
+
+// Opens the backup archive and reads its metadata, the reader is positioned at the dump
+func openBackupArchive(src string) (*BackupMetadata, *tar.Reader, func() error, error) {
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, fmt.Errorf("%s is not a backup archive: %s", src, err)
+	}
+	closeAll := func() error {
+		gz.Close()
+		return file.Close()
+	}
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if err != nil || header.Name != backupMetadataFile {
+		closeAll()
+		return nil, nil, nil, fmt.Errorf("%s is not a backup archive: missing %s", src, backupMetadataFile)
+	}
+	metadata := &BackupMetadata{}
+	if err := json.NewDecoder(tr).Decode(metadata); err != nil {
+		closeAll()
+		return nil, nil, nil, fmt.Errorf("invalid backup metadata: %s", err)
+	}
+	header, err = tr.Next()
+	if err != nil || header.Name != backupDumpFile {
+		closeAll()
+		return nil, nil, nil, fmt.Errorf("%s is not a backup archive: missing %s", src, backupDumpFile)
+	}
+	return metadata, tr, closeAll, nil
+}
+
+// Reads the metadata of a backup archive
+func ReadBackupMetadata(src string) (*BackupMetadata, error) {
+	metadata, _, closeArchive, err := openBackupArchive(src)
+	if err != nil {
+		return nil, err
+	}
+	closeArchive()
+	return metadata, nil
+}
+
+/*
+Returns an error when the archive can not be restored into this project. Archives of another database type or
+format are always refused, archives taken with another Conduit image tag are refused unless force is set
+*/
+func (c *Conduit) checkBackupCompatibility(metadata *BackupMetadata, force bool) error {
+	if metadata.FormatVersion != backupFormatVersion {
+		return fmt.Errorf("unsupported backup format version %d", metadata.FormatVersion)
+	}
+	if metadata.Database != c.json.Database {
+		return fmt.Errorf("the backup is of a %s database but the project uses %s", metadata.Database, c.json.Database)
+	}
+	imageTag, _ := c.ImageTags()
+	if metadata.ImageTag != imageTag && !force {
+		return fmt.Errorf("the backup was taken with conduit %s but the project runs %s, use --force to restore anyway", metadata.ImageTag, imageTag)
+	}
+	return nil
+}
+
+// Restores a backup archive with mongorestore or pg_restore inside of the running database container
+func (c *Conduit) RestoreDatabaseBackup(ctx context.Context, src string, force bool) (*BackupMetadata, error) {
+	command, ok := restoreCommands[c.json.Database]
+	if !ok {
+		return nil, errors.New("the project has no database")
+	}
+	metadata, dump, closeArchive, err := openBackupArchive(src)
+	if err != nil {
+		return nil, err
+	}
+	defer closeArchive()
+	if err := c.checkBackupCompatibility(metadata, force); err != nil {
+		return nil, err
+	}
+	stderr := &bytes.Buffer{}
+	options := &ExecOptions{Cmd: []string{"sh", "-c", command}, Interactive: true}
+	code, err := c.ExecStreams(ctx, c.json.Database, options, dump, io.Discard, stderr)
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("restoring %s exited with code %d: %s", c.json.Database, code, strings.TrimSpace(stderr.String()))
+	}
+	return metadata, nil
+}
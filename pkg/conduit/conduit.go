@@ -4,31 +4,58 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/fs"
 	"io/ioutil"
 	"os"
 
 	"github.com/isolateminds/go-conduit-cli/internal/compose"
 	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
 	"github.com/isolateminds/go-conduit-cli/internal/docker"
-	"github.com/isolateminds/go-conduit-cli/internal/utils"
+	"github.com/isolateminds/go-conduit-cli/internal/docker/execopt"
 	"github.com/isolateminds/go-conduit-cli/pkg/conduit/errordefs"
 )
 
 const (
-	//Hard coded for now I guess
+	//Template names relative to the template source
 
-	mongoEnvTemplateURL    = "https://raw.githubusercontent.com/isolateminds/go-conduit-cli/main/templates/mongo.env"
-	postgresEnvTemplateURL = "https://raw.githubusercontent.com/isolateminds/go-conduit-cli/main/templates/postgres.env"
+	envTemplate = "conduit.env"
 
-	dockerComposeTemplateURL = "https://raw.githubusercontent.com/isolateminds/go-conduit-cli/main/templates/docker-compose.yml"
+	dockerComposeTemplate = "docker-compose.yml"
 )
 
 type Conduit struct {
+	client   *docker.Client
 	composer *compose.Composer
 	json     *ConduitJson
+	// The rendered values of the variables the bootstrapper overrode, written next to the .env
+	appliedEnv map[string]string
+	// The options the project was loaded with, reused when it is reloaded
+	options []composeopt.SetComposerOptions
 }
 
+func (c *Conduit) ProjectName() string {
+	return c.json.ProjectName
+}
+
+// Returns the backup section of conduit.json, never nil
+func (c *Conduit) BackupConfig() BackupJson {
+	if c.json.Backup == nil {
+		return BackupJson{}
+	}
+	return *c.json.Backup
+}
+
+// Returns the database of the project either mongodb or postgres
+func (c *Conduit) Database() string {
+	return c.json.Database
+}
+
+// The profiles enabled automatically because an enabled profile depends on them keyed by the profiles that require them
+func (c *Conduit) RequiredProfiles() map[string][]string {
+	return c.composer.RequiredProfiles()
+}
 func (c *Conduit) Remove(ctx context.Context, services []string) error {
 	return c.composer.Remove(ctx, services)
 }
@@ -45,9 +72,162 @@ func (c *Conduit) Start(ctx context.Context, services []string) error {
 func (c *Conduit) Create(ctx context.Context, services []string) error {
 	return c.composer.Create(ctx, services)
 }
+func (c *Conduit) Logs(ctx context.Context, options types.LogOptions) error {
+	return c.composer.Logs(ctx, options)
+}
+func (c *Conduit) Run(ctx context.Context, options types.RunOptions) (int, error) {
+	return c.composer.Run(ctx, options)
+}
+func (c *Conduit) Images() map[string]string {
+	return c.composer.Images()
+}
+
+// Returns the compose project as the services will be run either as yaml or json
+func (c *Conduit) Config(ctx context.Context, format string) ([]byte, error) {
+	return c.composer.Config(ctx, format)
+}
+
+// Pins the images of the enabled services to the digests their tags currently resolve to
+func (c *Conduit) ResolveImageDigests(ctx context.Context) error {
+	return c.composer.ResolveImageDigests(ctx)
+}
+
+// Returns the enabled services sorted
+func (c *Conduit) Services() []string {
+	return c.composer.ServiceNames()
+}
+
+// Returns every profile the compose file defines
+func (c *Conduit) Profiles() []string {
+	return c.composer.ProfileNames()
+}
+
+// Returns the docker volume names keyed by their compose file keys
+func (c *Conduit) Volumes() map[string]string {
+	return c.composer.Volumes()
+}
+func (c *Conduit) Pull(ctx context.Context, services []string) error {
+	return c.composer.Pull(ctx, services)
+}
+
+// Returns the value each env layer sets for the key, lowest precedence first
+func (c *Conduit) EnvValues(key string) []types.EnvValue {
+	return c.composer.Options.Environment.Values(key)
+}
+
+// Returns the image tags set in the .env
+func (c *Conduit) ImageTags() (imageTag, uiImageTag string) {
+	variables := c.composer.Options.Environment.Variables
+	return variables["IMAGE_TAG"], variables["UI_IMAGE_TAG"]
+}
+
+// Sets the image tags in the in memory .env, empty tags are left unchanged.
+// Use WriteEnvFile to persist them
+func (c *Conduit) SetImageTags(imageTag, uiImageTag string) {
+	if imageTag != "" {
+		c.composer.Options.Environment.Set("IMAGE_TAG", imageTag)
+		c.json.Version = imageTag
+	}
+	if uiImageTag != "" {
+		c.composer.Options.Environment.Set("UI_IMAGE_TAG", uiImageTag)
+	}
+}
+
+func (c *Conduit) Status(ctx context.Context) ([]types.ServiceStatus, error) {
+	return c.composer.Status(ctx)
+}
+
+type ExecOptions struct {
+	Cmd        []string
+	User       string
+	WorkingDir string
+	// Environment variables in the form of KEY=VALUE
+	Env []string
+	// Allocate a pseudo-TTY
+	TTY bool
+	// Keep stdin open and forward it to the command
+	Interactive bool
+}
+
+// Runs a command inside of the running service container forwarding the standard streams and returns its exit code
+func (c *Conduit) Exec(ctx context.Context, service string, options *ExecOptions) (int, error) {
+	return c.ExecStreams(ctx, service, options, os.Stdin, os.Stdout, os.Stderr)
+}
 
-// For already bootstrapped projects must be in project root dir when you call this
-func NewConduitFromProject(ctx context.Context, detached bool, profiles []string) (*Conduit, error) {
+// Same as Exec but with the given streams, stdin is only read when Interactive is set
+func (c *Conduit) ExecStreams(ctx context.Context, service string, options *ExecOptions, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	name, err := c.composer.ServiceContainer(ctx, service)
+	if err != nil {
+		return -1, err
+	}
+	exec := c.client.NewExec(c.client.NewContainer(name), options.Cmd...)
+	exec.SetOptions(
+		execopt.User(options.User),
+		execopt.WorkingDir(options.WorkingDir),
+		execopt.Env(options.Env...),
+		execopt.AttachStdout(),
+		execopt.AttachStderr(),
+	)
+	if options.TTY {
+		exec.SetOptions(execopt.TTY())
+	}
+	if options.Interactive {
+		exec.SetOptions(execopt.AttachStdin())
+	}
+	return c.client.RunExec(ctx, exec, stdin, stdout, stderr)
+}
+
+/*
+Streams the stats of every running project container concurrently.
+Both channels are closed once every stream has ended, cancel the context to stop streaming
+*/
+func (c *Conduit) StreamStats(ctx context.Context) (<-chan docker.StatsSample, <-chan error) {
+	names, err := c.RunningContainers(ctx)
+	if err != nil {
+		return closedStatsStream(err)
+	}
+	if len(names) == 0 {
+		return closedStatsStream(errors.New("there are no running containers"))
+	}
+	containers := []*docker.Container{}
+	for _, name := range names {
+		containers = append(containers, c.client.NewContainer(name))
+	}
+	return c.client.StreamStats(ctx, containers...)
+}
+
+// Returns the names of the running project containers
+func (c *Conduit) RunningContainers(ctx context.Context) ([]string, error) {
+	statuses, err := c.composer.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, s := range statuses {
+		if s.State == "running" {
+			names = append(names, s.Container)
+		}
+	}
+	return names, nil
+}
+
+// Helper returns already closed stats channels with the error
+func closedStatsStream(err error) (<-chan docker.StatsSample, <-chan error) {
+	samples := make(chan docker.StatsSample)
+	errs := make(chan error, 1)
+	errs <- err
+	close(samples)
+	close(errs)
+	return samples, errs
+}
+
+// Selects the .env.<environment> layered on top of .env and .env.local eg: CONDUIT_ENV=staging loads .env.staging
+const EnvironmentVariable = "CONDUIT_ENV"
+
+// For already bootstrapped projects must be in project root dir when you call this.
+// Any additional options are applied last so they can override the defaults eg: a custom log consumer or
+// composeopt.WithComposeFiles to layer other files than the ones recorded in conduit.json
+func NewConduitFromProject(ctx context.Context, detached bool, profiles []string, options ...composeopt.SetComposerOptions) (*Conduit, error) {
 	//Automatically checks if connected to daemon
 	client, err := docker.NewClient(ctx)
 	if err != nil {
@@ -74,29 +254,47 @@ func NewConduitFromProject(ctx context.Context, detached bool, profiles []string
 
 	composer, err := compose.NewComposer(
 		data.ProjectName,
-		withDetachedFlag(ctx, detached),
-		composeopt.WithClient(client),
-		composeopt.WithEnvFromFile(".env"),
-		composeopt.WithYamlFromFile("docker-compose.yaml"),
-		//the profiles added here will be used with dcoker compose
-		composeopt.WithProfiles(updatedProfiles...),
+		append([]composeopt.SetComposerOptions{
+			withDetachedFlag(ctx, detached),
+			composeopt.WithClient(client),
+			//.env.local, .env.<CONDUIT_ENV> and the process environment override the generated .env
+			composeopt.WithLayeredEnvFromFile(".env", os.Getenv(EnvironmentVariable)),
+			composeopt.WithYamlFromFile("docker-compose.yaml"),
+			//local customizations layered on top so they survive regenerating docker-compose.yaml
+			composeopt.WithDefaultYamlOverrideFile(),
+			composeopt.WithComposeFiles(data.ComposeFiles...),
+			//the profiles added here will be used with dcoker compose
+			composeopt.WithProfiles(updatedProfiles...),
+		}, options...)...,
 	)
 	if err != nil {
 		return nil, errordefs.NewConduitFromProjectError(err)
 	}
 
 	return &Conduit{
+		client:   client,
 		composer: composer,
 		json: &ConduitJson{
-			ProjectName: data.ProjectName,
-			Version:     data.Version,
-			Database:    data.Database,
-			//filter the profiles here to save the actual profiles defined in the schema
-			Profiles: composer.FilterYamlProfiles(updatedProfiles),
+			ProjectName:  data.ProjectName,
+			Version:      data.Version,
+			Database:     data.Database,
+			Storage:      data.Storage,
+			ComposeFiles: data.ComposeFiles,
+			Rollback:     data.Rollback,
+			Backup:       data.Backup,
+			Applied:      data.Applied,
+			//save the resolved profiles so the ones required by dependencies stay enabled
+			Profiles: composer.Profiles(),
 		},
+		options: options,
 	}, nil
 }
 
+// Loads the project again with the options it was loaded with so the files written since are used
+func (c *Conduit) reload(ctx context.Context) (*Conduit, error) {
+	return NewConduitFromProject(ctx, true, []string{}, c.options...)
+}
+
 type BootstrapperOptions struct {
 	ProjectName   string
 	Profiles      []string
@@ -104,6 +302,12 @@ type BootstrapperOptions struct {
 	ImageTag      string
 	UIImageTag    string
 	MountDatabase bool
+	//Where the compose and env templates are read from, defaults to the embedded templates
+	TemplateSource composeopt.TemplateSource
+	//Set in the .env after the template is rendered
+	Env map[string]string
+	//Resource limits set on the services of the compose file
+	Resources map[string]ServiceResources
 }
 
 // For bootsrapping conduit projects and enabling profiles
@@ -113,10 +317,14 @@ func NewConduitBootstrapper(ctx context.Context, options *BootstrapperOptions) (
 	if err != nil {
 		return nil, errordefs.NewConduitBootstrapperError(err)
 	}
+	if options.TemplateSource == nil {
+		options.TemplateSource = composeopt.EmbeddedTemplateSource()
+	}
 	db, err := ensureProperDatabase(options.Profiles)
 	if err != nil {
 		return nil, errordefs.NewConduitBootstrapperError(err)
 	}
+	previous := map[string]string{}
 	composer, err := compose.NewComposer(
 		options.ProjectName,
 		composeopt.WithClient(client),
@@ -124,18 +332,24 @@ func NewConduitBootstrapper(ctx context.Context, options *BootstrapperOptions) (
 		composeopt.WithProfiles(options.Profiles...),
 		withDetachedFlag(ctx, options.Detached),
 		withEnvBasedOnDatabaseProfile(ctx, db, options),
+		withOverrides(options.Env, options.Resources, previous),
 	)
 	if err != nil {
 		return nil, errordefs.NewConduitBootstrapperError(err)
 	}
 	return &Conduit{
+		client:   client,
 		composer: composer,
 		json: &ConduitJson{
 			ProjectName: options.ProjectName,
+			Version:     options.ImageTag,
 			Database:    db,
-			//filter the profiles here to save the actual profiles defined in the schema
-			Profiles: composer.FilterYamlProfiles(options.Profiles),
+			Storage:     bootstrapStorage(db, options.MountDatabase),
+			//save the resolved profiles so the ones required by dependencies stay enabled
+			Profiles: composer.Profiles(),
+			Applied:  newAppliedJson(options.Env, options.Resources),
 		},
+		appliedEnv: previous,
 	}, nil
 }
 
@@ -144,9 +358,15 @@ func (c *Conduit) WriteComposeFile() error {
 	return os.WriteFile("docker-compose.yaml", c.composer.Options.Yaml.Bytes, fs.ModePerm)
 }
 
-// Writes the .env to current path
+// Writes the .env to current path along with the rendered values of the variables the bootstrapper overrode
 func (c *Conduit) WriteEnvFile() error {
-	return os.WriteFile(".env", c.composer.Options.Environment.Bytes, fs.ModePerm)
+	if err := os.WriteFile(".env", c.composer.Options.Environment.Bytes, fs.ModePerm); err != nil {
+		return err
+	}
+	if len(c.appliedEnv) > 0 {
+		return writeAppliedEnv(c.appliedEnv)
+	}
+	return nil
 }
 
 // Writes the json file to current path
@@ -156,10 +376,11 @@ func (c *Conduit) WriteConduitJsonFile() error {
 
 // modifies the docker compose file if MountDatabase set
 func withYamlBasedOnDatabaseBind(ctx context.Context, db string, options *BootstrapperOptions) composeopt.SetComposerOptions {
+	var bind composeopt.YamlFormatter
 	if options.MountDatabase {
-		return composeopt.WithYamlFromUrlFormatter(dockerComposeTemplateURL, newComposeBindDbFormatter(db))
+		bind = newComposeBindDbFormatter(db)
 	}
-	return composeopt.WithYamlFromUrl(dockerComposeTemplateURL)
+	return composeopt.WithYamlFromTemplate(options.TemplateSource, dockerComposeTemplate, newComposeTemplateFormatter(newTemplateData(db, options), bind))
 }
 
 // If detatched no logging will be done, same as --detach or -d flag in docker compose
@@ -170,29 +391,25 @@ func withDetachedFlag(ctx context.Context, detached bool) composeopt.SetComposer
 	return composeopt.WithDefaultComposeLogConsumer(ctx)
 }
 
-/*
-Fetches either the mongodb .env  template or the postgres one depending on profiles
-and formats the env template
-*/
-func withEnvBasedOnDatabaseProfile(ctx context.Context, db string, options *BootstrapperOptions) composeopt.SetComposerOptions {
-	dbPass := utils.GenerateRandomString(32)
-	masterKey := utils.GenerateRandomString(64)
-	vMap := variableMap{
-		"MasterKey":   masterKey,
+// The values available to the templates, secrets are generated by the templates with randomSecret
+func newTemplateData(db string, options *BootstrapperOptions) templateData {
+	return templateData{
+		"Database":    db,
 		"ImageTag":    options.ImageTag,
 		"UIImageTag":  options.UIImageTag,
 		"ProjectName": options.ProjectName,
+		"Profiles":    options.Profiles,
 	}
-	switch db {
-	case "mongodb":
-		vMap["MongoPassword"] = dbPass
-		return composeopt.WithEnvFromUrlFormatter(mongoEnvTemplateURL, newEnvFormatter(vMap))
-	case "postgres":
-		vMap["PostgresPassword"] = dbPass
-		return composeopt.WithEnvFromUrlFormatter(postgresEnvTemplateURL, newEnvFormatter(vMap))
-	default:
+}
+
+// Renders the .env template for the database profile
+func withEnvBasedOnDatabaseProfile(ctx context.Context, db string, options *BootstrapperOptions) composeopt.SetComposerOptions {
+	if db == "" {
 		return composeopt.WithError("a database profile has not been given use")
 	}
+	//sources with the older mongo.env and postgres.env layout still work
+	src := newLegacyEnvTemplateSource(options.TemplateSource, db)
+	return composeopt.WithEnvFromTemplate(src, envTemplate, newEnvFormatter(newTemplateData(db, options)))
 }
 
 // Helper function 'blocks' profiles from being included in the result.
@@ -2,32 +2,57 @@ package conduit
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
-	"strings"
+	"path"
 
 	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
 	"github.com/isolateminds/go-conduit-cli/internal/docker"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
-type variableMap map[string]string
 type envFormatter struct {
-	formatter   composeopt.EnvFormatter
-	VariableMap variableMap
+	formatter composeopt.EnvFormatter
+	engine    *templateEngine
 }
 
 func (tf *envFormatter) Format(in []byte) (out io.Reader, err error) {
-	env := string(in)
-	for k, v := range tf.VariableMap {
-		env = strings.ReplaceAll(env, fmt.Sprintf("{{%s}}", k), v)
+	env, err := tf.engine.Render(envTemplate, in)
+	if err != nil {
+		return nil, err
 	}
-	return bytes.NewReader([]byte(env)), nil
+	return bytes.NewReader(env), nil
 }
 
-func newEnvFormatter(variableMap variableMap) *envFormatter {
+func newEnvFormatter(data templateData) *envFormatter {
 	return &envFormatter{
-		VariableMap: variableMap,
+		engine: newTemplateEngine(data),
+	}
+}
+
+// Renders the compose template then passes it on to the next formatter if there is one
+type composeTemplateFormatter struct {
+	formatter composeopt.YamlFormatter
+	engine    *templateEngine
+	next      composeopt.YamlFormatter
+}
+
+func (f *composeTemplateFormatter) Format(in []byte) (out []byte, err error) {
+	out, err = f.engine.Render(dockerComposeTemplate, in)
+	if err != nil {
+		return nil, err
+	}
+	if f.next == nil {
+		return out, nil
+	}
+	return f.next.Format(out)
+}
+
+func newComposeTemplateFormatter(data templateData, next composeopt.YamlFormatter) *composeTemplateFormatter {
+	return &composeTemplateFormatter{
+		engine: newTemplateEngine(data),
+		next:   next,
 	}
 }
 
@@ -37,39 +62,135 @@ type composeBindDbFormatter struct {
 }
 
 func (f *composeBindDbFormatter) Format(in []byte) (out []byte, err error) {
-	t := &DockerCompose{}
-	err = yaml.Unmarshal(in, t)
+	var other string
+	switch f.DatabaseName {
+	case "mongodb":
+		other = "postgres"
+	case "postgres":
+		other = "mongodb"
+	default:
+		return nil, fmt.Errorf("invalid database name %s", f.DatabaseName)
+	}
+	doc, root, err := decodeCompose(in)
 	if err != nil {
 		return nil, err
 	}
-	delete(t.Volumes, "mongo")
-	delete(t.Volumes, "postgres")
-
-	for name := range t.Services {
-		switch f.DatabaseName {
-		case "mongodb":
-			delete(t.Services, "postgres")
-			if service, ok := t.Services[name]; ok {
-				service.Volumes = []string{"./database/:/data/db"}
-				t.Services[name] = service
+	services := mappingValue(root, "services")
+	database := mappingValue(services, f.DatabaseName)
+	if database == nil {
+		return nil, fmt.Errorf("the compose file has no %s service", f.DatabaseName)
+	}
+	//named volumes that may no longer be used once the edits are done
+	unused := []string{}
+	if removed := deleteMappingKey(services, other); removed != nil {
+		if volumes := mappingValue(removed, "volumes"); volumes != nil {
+			for _, entry := range volumes.Content {
+				unused = append(unused, volumeSource(entry))
 			}
-		case "postgres":
-			delete(t.Services, "mongodb")
-			if service, ok := t.Services[name]; ok {
-				service.Volumes = []string{"./database/:/var/lib/postgresql/data"}
-				t.Services[name] = service
+		}
+	}
+	topLevelVolumes := mappingValue(root, "volumes")
+	bound := false
+	if volumes := mappingValue(database, "volumes"); volumes != nil {
+		for _, entry := range volumes.Content {
+			source := volumeSource(entry)
+			if mappingValue(topLevelVolumes, source) == nil {
+				continue
 			}
-
-		default:
-			return nil, fmt.Errorf("invalid database name %s", f.DatabaseName)
+			setBindSource(entry, "./"+databaseBindDir+"/")
+			unused = append(unused, source)
+			bound = true
 		}
 	}
+	if !bound {
+		return nil, fmt.Errorf("the %s service has no named volume to replace with a bind mount", f.DatabaseName)
+	}
+	for _, volume := range unused {
+		if volume != "" && !isVolumeReferenced(services, volume) {
+			deleteMappingKey(topLevelVolumes, volume)
+		}
+	}
+
+	return encodeCompose(doc)
+}
+
+type composeVolumeDbFormatter struct {
+	DatabaseName string
+	formatter    composeopt.YamlFormatter
+}
 
-	out, err = yaml.Marshal(t)
+func (f *composeVolumeDbFormatter) Format(in []byte) (out []byte, err error) {
+	volume := databaseVolumeName(f.DatabaseName)
+	if volume == "" {
+		return nil, fmt.Errorf("invalid database name %s", f.DatabaseName)
+	}
+	doc, root, err := decodeCompose(in)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	database := mappingValue(mappingValue(root, "services"), f.DatabaseName)
+	if database == nil {
+		return nil, fmt.Errorf("the compose file has no %s service", f.DatabaseName)
+	}
+	replaced := false
+	if volumes := mappingValue(database, "volumes"); volumes != nil {
+		for _, entry := range volumes.Content {
+			if path.Clean(volumeSource(entry)) == databaseBindDir {
+				setNamedVolumeSource(entry, volume)
+				replaced = true
+			}
+		}
+	}
+	if !replaced {
+		return nil, fmt.Errorf("the %s service does not bind mount ./%s", f.DatabaseName, databaseBindDir)
+	}
+	topLevelVolumes := ensureMapping(root, "volumes")
+	if mappingValue(topLevelVolumes, volume) == nil {
+		topLevelVolumes.Content = append(topLevelVolumes.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: volume},
+			&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "external"},
+				{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"},
+			}},
+		)
+	}
+	return encodeCompose(doc)
+}
+
+// Returns the named volume the templates use for the database
+func databaseVolumeName(db string) string {
+	switch db {
+	case "mongodb":
+		return "mongo"
+	case "postgres":
+		return "postgres"
+	default:
+		return ""
+	}
+}
+
+func decodeCompose(in []byte) (doc, root *yaml.Node, err error) {
+	doc = &yaml.Node{}
+	if err := yaml.Unmarshal(in, doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil, errors.New("the compose file is empty")
+	}
+	return doc, doc.Content[0], nil
+}
+
+func encodeCompose(doc *yaml.Node) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 type bindFormatterOptions struct {
@@ -78,10 +199,16 @@ type bindFormatterOptions struct {
 }
 
 /*
-This YamlFormatter formats the docker compose file by setting a bind mounts for the db specified
-It also deletes anything database related of the opposite database so if dbName is mongodb
-all postgres related keys will be deleted
+This YamlFormatter formats the docker compose file by replacing the named volume of the db specified
+with a bind mount to ./database. It also deletes the service of the opposite database so if dbName is mongodb
+the postgres service is deleted, along with the named volumes nothing uses anymore.
+Everything else in the file including comments is left as is
 */
 func newComposeBindDbFormatter(dbName string) *composeBindDbFormatter {
 	return &composeBindDbFormatter{DatabaseName: dbName}
 }
+
+// The inverse of newComposeBindDbFormatter, replaces the ./database bind mount of the db with its named volume
+func newComposeVolumeDbFormatter(dbName string) *composeVolumeDbFormatter {
+	return &composeVolumeDbFormatter{DatabaseName: dbName}
+}
@@ -0,0 +1,93 @@
+package conduit
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func TestComposeBindDbFormatterGolden(t *testing.T) {
+	in, err := os.ReadFile(filepath.Join("testdata", "compose_bind_input.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		database string
+		target   string
+		removed  string
+	}{
+		{database: "mongodb", target: "/bitnami/mongodb", removed: "postgres"},
+		{database: "postgres", target: "/var/lib/postgresql", removed: "mongodb"},
+	}
+	for _, test := range tests {
+		t.Run(test.database, func(t *testing.T) {
+			out, err := newComposeBindDbFormatter(test.database).Format(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "compose_bind_"+test.database+".golden.yaml")
+			if *update {
+				if err := os.WriteFile(golden, out, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, want) {
+				t.Errorf("output does not match %s\ngot:\n%s\nwant:\n%s", golden, out, want)
+			}
+
+			//the comments and the keys the formatter does not know about survive
+			for _, kept := range []string{
+				"# Conduit services",
+				"# keep core up",
+				"# named volumes",
+				"restart: unless-stopped",
+				"x-conduit-owner: platform",
+				"logging: *logging",
+				"host.docker.internal:host-gateway",
+			} {
+				if !strings.Contains(string(out), kept) {
+					t.Errorf("%q is missing from the output", kept)
+				}
+			}
+
+			_, root, err := decodeCompose(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			services := mappingValue(root, "services")
+			if mappingValue(services, test.removed) != nil {
+				t.Errorf("the %s service was not removed", test.removed)
+			}
+			database := mappingValue(services, test.database)
+			if database == nil {
+				t.Fatalf("the %s service was removed", test.database)
+			}
+			if restart := mappingValue(database, "restart"); restart == nil || restart.Value != "always" {
+				t.Errorf("the restart policy of %s was lost", test.database)
+			}
+			if source := volumeSource(mappingValue(database, "volumes").Content[0]); source != "./database/" {
+				t.Errorf("the %s data volume source is %s want ./database/", test.database, source)
+			}
+			//only the database service is rebound
+			if source := volumeSource(mappingValue(mappingValue(services, "prometheus"), "volumes").Content[0]); source != "prometheus" {
+				t.Errorf("the prometheus volume source is %s want prometheus", source)
+			}
+			volumes := mappingKeys(mappingValue(root, "volumes"))
+			if len(volumes) != 1 || volumes[0] != "prometheus" {
+				t.Errorf("the top level volumes are %v want [prometheus]", volumes)
+			}
+			if !strings.Contains(string(out), ":"+test.target) {
+				t.Errorf("the %s mount target %s was lost", test.database, test.target)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package conduit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+	"github.com/isolateminds/go-conduit-cli/internal/docker/containeropt"
+	"github.com/isolateminds/go-conduit-cli/internal/docker/hostopt"
+	"github.com/isolateminds/go-conduit-cli/internal/utils"
+)
+
+// Small image used for helper containers that copy data in and out of volumes
+const helperImage = "docker.io/library/alpine:3.18"
+
+/*
+Runs a short lived helper container with the mounts and waits for it to exit.
+The helper image is pulled if it is missing and the container is always removed afterwards
+*/
+func (c *Conduit) runHelperContainer(ctx context.Context, mounts []types.Mount, cmd ...string) error {
+	_, err := c.runHelperContainerOutput(ctx, mounts, cmd...)
+	return err
+}
+
+// Same as runHelperContainer but returns what the command wrote to stdout
+func (c *Conduit) runHelperContainerOutput(ctx context.Context, mounts []types.Mount, cmd ...string) (string, error) {
+	image := c.client.NewImage(helperImage)
+	container := c.client.NewContainer(fmt.Sprintf("%s-helper-%s", c.json.ProjectName, utils.GenerateRandomString(8)))
+	container.SetOptions(
+		containeropt.Image(image),
+		containeropt.CMD(cmd...),
+	)
+	for _, m := range mounts {
+		container.SetHostOptions(hostopt.Mount(hostopt.MountType(m.Type), m.Source, m.Target, false))
+	}
+
+	err := c.client.CreateContainer(ctx, container)
+	if client.IsErrNotFound(err) {
+		c.client.SetImageResponeWriter(io.Discard)
+		if err := c.client.PullImage(ctx, image); err != nil {
+			return "", err
+		}
+		err = c.client.CreateContainer(ctx, container)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer c.client.RemoveContainer(context.Background(), container, true)
+
+	if err := c.client.StartContainer(ctx, container); err != nil {
+		return "", err
+	}
+	code, err := c.client.WaitContainer(ctx, container)
+	if err != nil {
+		return "", err
+	}
+	stdout, stderr, err := c.client.ContainerOutput(ctx, container)
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", fmt.Errorf("helper container %s exited with code %d: %s", container, code, strings.TrimSpace(stderr))
+	}
+	return stdout, nil
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 /*
@@ -15,6 +18,45 @@ type ConduitJson struct {
 	Version     string   `json:"version"`
 	Database    string   `json:"database"`
 	Profiles    []string `json:"profiles"`
+	// Either volume or bind when the database is bind mounted to ./database
+	Storage string `json:"storage,omitempty"`
+	// Compose files layered after docker-compose.yaml and docker-compose.override.yaml in order
+	ComposeFiles []string      `json:"composeFiles,omitempty"`
+	Rollback     *RollbackJson `json:"rollback,omitempty"`
+	Backup       *BackupJson   `json:"backup,omitempty"`
+	Applied      *AppliedJson  `json:"applied,omitempty"`
+}
+
+// What deploy setup -f and deploy apply set from a manifest so apply can revert what is removed from it
+type AppliedJson struct {
+	// The .env variables set from the env and ports, the values they had before are kept in backups/applied.env
+	Env []string `json:"env,omitempty"`
+	// The resource limits set in docker-compose.yaml keyed by service name
+	Resources map[string]ServiceResources `json:"resources,omitempty"`
+}
+
+// Defaults for deploy db backup, the flags take precedence
+type BackupJson struct {
+	// eg: @every 6h or @daily
+	Schedule string `json:"schedule,omitempty"`
+	// Number of archives to keep, 0 keeps all of them
+	Keep int `json:"keep,omitempty"`
+	// Archives older than this are deleted eg: 168h, empty keeps all of them
+	MaxAge string `json:"maxAge,omitempty"`
+	// Where the archives are written relative to the project root
+	Dir string `json:"dir,omitempty"`
+}
+
+// Everything needed to undo the last deploy update
+type RollbackJson struct {
+	Version    string `json:"version"`
+	ImageTag   string `json:"imageTag"`
+	UIImageTag string `json:"uiImageTag"`
+	// Path of the copy of the .env taken before the update relative to the project root
+	EnvFile string `json:"envFile"`
+	// Path of the database snapshot archive, empty if none was taken
+	Snapshot  string    `json:"snapshot,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // Writes the conduit.json file to current path
@@ -29,3 +71,38 @@ func (cj *ConduitJson) WriteFile() error {
 	}
 	return nil
 }
+
+/*
+Records the compose files layered after docker-compose.yaml and docker-compose.override.yaml
+in the conduit.json of the current path, an empty list removes them.
+Files inside the project directory are stored relative to it
+*/
+func SetComposeFiles(files []string) error {
+	data := &ConduitJson{}
+	b, err := os.ReadFile("conduit.json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, data); err != nil {
+		return err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	data.ComposeFiles = []string{}
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			abs = rel
+		}
+		data.ComposeFiles = append(data.ComposeFiles, abs)
+	}
+	return data.WriteFile()
+}
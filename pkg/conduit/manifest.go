@@ -0,0 +1,512 @@
+package conduit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Declares a whole project for deploy setup -f and deploy apply -f
+
+	projectName: conduit
+	database: mongodb
+	modules: [chat, email]
+	imageTag: v0.16.0             # setup defaults to latest, apply keeps the current tag when it is missing
+	uiImageTag: v0.16.0
+	storage: volume               # setup defaults to volume, apply keeps the current storage when it is missing
+	ports:
+	  ADMIN_HTTP_PORT: 4030
+	env:
+	  CLIENT_DEFAULT_HOST_URL: http://localhost:4000
+	resources:
+	  core:
+	    cpus: "1.5"
+	    memory: 512m
+*/
+type Manifest struct {
+	ProjectName string   `yaml:"projectName"`
+	Database    string   `yaml:"database"`
+	Modules     []string `yaml:"modules"`
+	ImageTag    string   `yaml:"imageTag"`
+	UIImageTag  string   `yaml:"uiImageTag"`
+	// Either volume or bind
+	Storage string `yaml:"storage"`
+	// Port variables of the .env eg: ADMIN_HTTP_PORT or DB_PORT
+	Ports map[string]int `yaml:"ports"`
+	// Extra or overridden .env variables
+	Env map[string]string `yaml:"env"`
+	// Resource limits keyed by service name
+	Resources map[string]ServiceResources `yaml:"resources"`
+}
+
+type ServiceResources struct {
+	// Number of cpus eg: "0.5"
+	CPUs string `yaml:"cpus" json:"cpus,omitempty"`
+	// Memory limit eg: 512m or 2g
+	Memory string `yaml:"memory" json:"memory,omitempty"`
+}
+
+// Keeps the values the .env variables set from a manifest had before in the backups directory
+const appliedEnvFile = "applied.env"
+
+var (
+	projectNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	portKeyRe     = regexp.MustCompile(`^[A-Z0-9_]*PORT[A-Z0-9_]*$`)
+	envKeyRe      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	memoryRe      = regexp.MustCompile(`(?i)^[0-9]+(\.[0-9]+)?[bkmg]?$`)
+	// Variables with a dedicated manifest field
+	reservedEnvKeys = []string{"IMAGE_TAG", "UI_IMAGE_TAG", "COMPOSE_PROJECT_NAME", "DB_TYPE"}
+)
+
+// Reads the manifest, unknown keys are rejected. The defaults are applied and the manifest is validated
+func LoadManifest(src string) (*Manifest, error) {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %s", src, err)
+	}
+	m.setDefaults()
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s:\n%s", src, err)
+	}
+	return m, nil
+}
+
+func (m *Manifest) setDefaults() {
+	if m.ProjectName == "" {
+		m.ProjectName = "conduit"
+	}
+}
+
+// Returns every problem with the manifest joined in a single error
+func (m *Manifest) Validate() error {
+	errs := []error{}
+	if !projectNameRe.MatchString(m.ProjectName) {
+		errs = append(errs, fmt.Errorf("projectName %q must be lowercase letters, digits, dashes and underscores", m.ProjectName))
+	}
+	if m.Database != "mongodb" && m.Database != "postgres" {
+		errs = append(errs, fmt.Errorf("database %q must be mongodb or postgres", m.Database))
+	}
+	seen := map[string]bool{}
+	for _, module := range m.Modules {
+		if module == "mongodb" || module == "postgres" {
+			errs = append(errs, fmt.Errorf("modules: %s is a database, use the database key", module))
+		}
+		if seen[module] {
+			errs = append(errs, fmt.Errorf("modules: %s is listed twice", module))
+		}
+		seen[module] = true
+	}
+	if m.Storage != "" && m.Storage != StorageVolume && m.Storage != StorageBind {
+		errs = append(errs, fmt.Errorf("storage %q must be %s or %s", m.Storage, StorageVolume, StorageBind))
+	}
+	for _, key := range sortedKeys(m.Ports) {
+		if !portKeyRe.MatchString(key) {
+			errs = append(errs, fmt.Errorf("ports: %s must be a port variable of the .env eg: ADMIN_HTTP_PORT", key))
+		}
+		if port := m.Ports[key]; port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("ports: %s %d must be between 1 and 65535", key, port))
+		}
+	}
+	for _, key := range sortedKeys(m.Env) {
+		if !envKeyRe.MatchString(key) {
+			errs = append(errs, fmt.Errorf("env: %s is not a valid variable name", key))
+		}
+		if slices.Contains(reservedEnvKeys, key) {
+			errs = append(errs, fmt.Errorf("env: %s is set from the manifest fields and can not be overridden", key))
+		}
+		if _, ok := m.Ports[key]; ok {
+			errs = append(errs, fmt.Errorf("env: %s is already set in ports", key))
+		}
+	}
+	for _, service := range sortedKeys(m.Resources) {
+		r := m.Resources[service]
+		if r.CPUs == "" && r.Memory == "" {
+			errs = append(errs, fmt.Errorf("resources: %s must set cpus or memory", service))
+		}
+		if r.CPUs != "" {
+			if cpus, err := strconv.ParseFloat(r.CPUs, 64); err != nil || cpus <= 0 {
+				errs = append(errs, fmt.Errorf("resources: %s cpus %q must be a positive number", service, r.CPUs))
+			}
+		}
+		if r.Memory != "" && !memoryRe.MatchString(r.Memory) {
+			errs = append(errs, fmt.Errorf("resources: %s memory %q must be a size eg: 512m or 2g", service, r.Memory))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns an error listing the modules that are not profiles of the compose file
+func (m *Manifest) ValidateModules(available []string) error {
+	unknown := []string{}
+	for _, module := range m.Modules {
+		if !slices.Contains(available, module) {
+			unknown = append(unknown, module)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown modules %v available modules are %v", unknown, available)
+	}
+	return nil
+}
+
+// The database followed by the modules
+func (m *Manifest) Profiles() []string {
+	return append([]string{m.Database}, m.Modules...)
+}
+
+// The ports and env merged into the .env overrides
+func (m *Manifest) EnvOverrides() map[string]string {
+	env := map[string]string{}
+	for key, value := range m.Env {
+		env[key] = value
+	}
+	for key, port := range m.Ports {
+		env[key] = strconv.Itoa(port)
+	}
+	return env
+}
+
+// Translates the manifest into the bootstrapper options, a new project uses the latest images and volume storage
+// unless told otherwise
+func (m *Manifest) BootstrapperOptions(detached bool, src composeopt.TemplateSource) *BootstrapperOptions {
+	imageTag, uiImageTag := m.ImageTag, m.UIImageTag
+	if imageTag == "" {
+		imageTag = "latest"
+	}
+	if uiImageTag == "" {
+		uiImageTag = "latest"
+	}
+	return &BootstrapperOptions{
+		ProjectName:    m.ProjectName,
+		Profiles:       m.Profiles(),
+		Detached:       detached,
+		ImageTag:       imageTag,
+		UIImageTag:     uiImageTag,
+		MountDatabase:  m.Storage == StorageBind,
+		TemplateSource: src,
+		Env:            m.EnvOverrides(),
+		Resources:      m.Resources,
+	}
+}
+
+// Applies the env overrides and resource limits on top of the rendered templates, the rendered values of the
+// overridden variables are added to previous
+func withOverrides(env map[string]string, resources map[string]ServiceResources, previous map[string]string) composeopt.SetComposerOptions {
+	return func(opt *types.ComposerOptions) error {
+		for _, key := range sortedKeys(env) {
+			if value, ok := opt.Environment.Lookup(key); ok {
+				previous[key] = value
+			}
+			opt.Environment.Set(key, env[key])
+		}
+		if len(resources) == 0 {
+			return nil
+		}
+		b, err := newComposeResourcesFormatter(resources).Format(opt.Yaml.Bytes)
+		if err != nil {
+			return err
+		}
+		opt.Yaml.Bytes = b
+		return nil
+	}
+}
+
+/*
+Reconciles the project with the manifest and returns what changed. The project name and database can not change.
+The storage is migrated, the image tags, env overrides, resource limits and modules are updated, the services of
+removed modules are removed and the services whose configuration diverged are recreated. The env overrides and
+resource limits applied before that are no longer in the manifest are reverted, missing image tags and storage are
+left as is
+*/
+func (c *Conduit) Apply(ctx context.Context, m *Manifest) ([]string, error) {
+	if m.ProjectName != c.json.ProjectName {
+		return nil, fmt.Errorf("the manifest is for project %s but this is %s", m.ProjectName, c.json.ProjectName)
+	}
+	if m.Database != c.json.Database {
+		return nil, fmt.Errorf("the database can not change from %s to %s, use project export and setup a new project instead", c.json.Database, m.Database)
+	}
+	_, modules := c.Modules()
+	if err := m.ValidateModules(modules); err != nil {
+		return nil, err
+	}
+	changes := []string{}
+	con := c
+	storage, err := con.Storage()
+	if err != nil {
+		return nil, err
+	}
+	//a missing storage keeps the current one so leaving it out never moves the database
+	if m.Storage != "" && storage != m.Storage {
+		if err := con.MigrateStorage(ctx, m.Storage); err != nil {
+			return nil, err
+		}
+		changes = append(changes, fmt.Sprintf("migrated the database to %s storage", m.Storage))
+		if con, err = con.reload(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	applied := con.json.Applied
+	if applied == nil {
+		applied = &AppliedJson{}
+	}
+	previous, err := readAppliedEnv()
+	if err != nil {
+		return nil, err
+	}
+	env := con.composer.Options.Environment
+	before := string(env.Bytes)
+	//missing tags keep the current ones so leaving them out never upgrades the project
+	con.SetImageTags(m.ImageTag, m.UIImageTag)
+	overrides := m.EnvOverrides()
+	reverted := []string{}
+	for _, key := range applied.Env {
+		if _, ok := overrides[key]; ok {
+			continue
+		}
+		//variables the manifest added are removed, the others get the value they had before
+		if value, ok := previous[key]; ok {
+			env.Set(key, value)
+		} else {
+			env.Unset(key)
+		}
+		delete(previous, key)
+		reverted = append(reverted, key)
+	}
+	for _, key := range sortedKeys(overrides) {
+		if !slices.Contains(applied.Env, key) {
+			if value, ok := env.Lookup(key); ok {
+				previous[key] = value
+			}
+		}
+		env.Set(key, overrides[key])
+	}
+	if err := writeAppliedEnv(previous); err != nil {
+		return nil, err
+	}
+	if string(env.Bytes) != before {
+		if err := con.WriteEnvFile(); err != nil {
+			return nil, err
+		}
+		changes = append(changes, "updated .env")
+	}
+	if len(reverted) > 0 {
+		changes = append(changes, fmt.Sprintf("reverted %v removed from the manifest", reverted))
+	}
+	//limits removed from the manifest are deleted
+	removedResources := map[string]ServiceResources{}
+	for _, service := range sortedKeys(applied.Resources) {
+		r, next := applied.Resources[service], m.Resources[service]
+		if next.CPUs != "" {
+			r.CPUs = ""
+		}
+		if next.Memory != "" {
+			r.Memory = ""
+		}
+		if r.CPUs != "" || r.Memory != "" {
+			removedResources[service] = r
+		}
+	}
+	if len(m.Resources) > 0 || len(removedResources) > 0 {
+		formatter := newComposeResourcesFormatter(m.Resources)
+		formatter.removed = removedResources
+		b, err := formatter.Format(con.composer.Options.Yaml.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(b, con.composer.Options.Yaml.Bytes) {
+			con.composer.Options.Yaml.Bytes = b
+			if err := con.WriteComposeFile(); err != nil {
+				return nil, err
+			}
+			changes = append(changes, "updated the resource limits in docker-compose.yaml")
+		}
+	}
+
+	//include the profiles the modules depend on
+	desired, required, err := con.composer.ResolveProfiles(m.Profiles())
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range sortedKeys(required) {
+		if slices.Contains(con.json.Profiles, profile) {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("enabled %s because %v requires it", profile, required[profile]))
+	}
+	removed := []string{}
+	for _, profile := range con.json.Profiles {
+		if !slices.Contains(desired, profile) {
+			removed = append(removed, profile)
+		}
+	}
+	if len(removed) > 0 {
+		if services := exclusiveServices(con, removed, desired); len(services) > 0 {
+			if err := con.Remove(ctx, services); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, fmt.Sprintf("disabled %v", removed))
+	}
+	added := []string{}
+	for _, profile := range desired {
+		if !slices.Contains(con.json.Profiles, profile) {
+			added = append(added, profile)
+		}
+	}
+	if len(added) > 0 {
+		changes = append(changes, fmt.Sprintf("enabled %v", added))
+	}
+	con.json.Profiles = desired
+	con.json.Applied = newAppliedJson(overrides, m.Resources)
+	if err := con.WriteConduitJsonFile(); err != nil {
+		return nil, err
+	}
+
+	//reload so the new env, compose file and profiles are used
+	next, err := con.reload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.composer.Reconcile(ctx); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Returns what was set from the env overrides and resource limits of a manifest or nil when nothing was
+func newAppliedJson(env map[string]string, resources map[string]ServiceResources) *AppliedJson {
+	if len(env) == 0 && len(resources) == 0 {
+		return nil
+	}
+	return &AppliedJson{Env: sortedKeys(env), Resources: resources}
+}
+
+// Reads the values the .env variables set from a manifest had before, empty when nothing was recorded
+func readAppliedEnv() (map[string]string, error) {
+	env, err := types.NewEnvFromFile(filepath.Join(backupsDir, appliedEnvFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return env.Variables, nil
+}
+
+// Writes the values the .env variables set from a manifest had before to the gitignored backups directory
+// because they can be secrets eg: CORE_MASTER_KEY, removes the file when there is nothing to keep
+func writeAppliedEnv(previous map[string]string) error {
+	dst := filepath.Join(backupsDir, appliedEnvFile)
+	if len(previous) == 0 {
+		if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if _, err := ensureBackupsDir(); err != nil {
+		return err
+	}
+	env := types.NewEnvironment(map[string]string{})
+	for _, key := range sortedKeys(previous) {
+		env.Set(key, previous[key])
+	}
+	return os.WriteFile(dst, env.Bytes, fs.ModePerm)
+}
+
+// Splits profiles into the database profiles and the module profiles
+func splitDatabaseProfiles(profiles []string) (databases, modules []string) {
+	databases, modules = []string{}, []string{}
+	for _, profile := range profiles {
+		if profile == "mongodb" || profile == "postgres" {
+			databases = append(databases, profile)
+		} else {
+			modules = append(modules, profile)
+		}
+	}
+	sort.Strings(databases)
+	sort.Strings(modules)
+	return databases, modules
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Sets the deploy.resources.limits of the services and deletes the removed ones
+type composeResourcesFormatter struct {
+	resources map[string]ServiceResources
+	removed   map[string]ServiceResources
+}
+
+func (f *composeResourcesFormatter) Format(in []byte) (out []byte, err error) {
+	doc, root, err := decodeCompose(in)
+	if err != nil {
+		return nil, err
+	}
+	services := mappingValue(root, "services")
+	for _, name := range sortedKeys(f.resources) {
+		service := mappingValue(services, name)
+		if service == nil {
+			return nil, fmt.Errorf("resources: the compose file has no %s service", name)
+		}
+		limits := ensureMapping(ensureMapping(ensureMapping(service, "deploy"), "resources"), "limits")
+		if r := f.resources[name]; r.CPUs != "" {
+			setMappingScalar(limits, "cpus", r.CPUs)
+		}
+		if r := f.resources[name]; r.Memory != "" {
+			setMappingScalar(limits, "memory", r.Memory)
+		}
+	}
+	for _, name := range sortedKeys(f.removed) {
+		service := mappingValue(services, name)
+		deploy := mappingValue(service, "deploy")
+		resources := mappingValue(deploy, "resources")
+		limits := mappingValue(resources, "limits")
+		if r := f.removed[name]; r.CPUs != "" {
+			deleteMappingKey(limits, "cpus")
+		}
+		if r := f.removed[name]; r.Memory != "" {
+			deleteMappingKey(limits, "memory")
+		}
+		//the mappings left empty are dropped so the service looks as it did before
+		for _, empty := range []struct {
+			parent *yaml.Node
+			key    string
+			value  *yaml.Node
+		}{{resources, "limits", limits}, {deploy, "resources", resources}, {service, "deploy", deploy}} {
+			if empty.value != nil && len(empty.value.Content) == 0 {
+				deleteMappingKey(empty.parent, empty.key)
+			}
+		}
+	}
+	return encodeCompose(doc)
+}
+
+func newComposeResourcesFormatter(resources map[string]ServiceResources) *composeResourcesFormatter {
+	return &composeResourcesFormatter{resources: resources}
+}
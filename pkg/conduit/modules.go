@@ -0,0 +1,138 @@
+package conduit
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
+
+// Returns the enabled modules and every module the compose file defines, databases are not modules
+func (c *Conduit) Modules() (active, available []string) {
+	_, active = splitDatabaseProfiles(c.json.Profiles)
+	_, available = splitDatabaseProfiles(c.composer.ProfileNames())
+	return active, available
+}
+
+/*
+Adds the modules and the profiles they depend on to conduit.json then creates and starts their services.
+Modules that are already enabled are skipped. Returns the modules that are active afterwards
+and the profiles that were added automatically keyed by the modules that require them
+*/
+func (c *Conduit) EnableModules(ctx context.Context, modules []string) (active []string, required map[string][]string, err error) {
+	active, err = c.checkModules(modules)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, required, err := c.composer.ResolveProfiles(append(append([]string{}, c.json.Profiles...), modules...))
+	if err != nil {
+		return nil, nil, err
+	}
+	added := []string{}
+	for _, profile := range resolved {
+		if !slices.Contains(c.json.Profiles, profile) {
+			added = append(added, profile)
+		}
+	}
+	if len(added) == 0 {
+		return active, required, nil
+	}
+	services := exclusiveServices(c, added, c.json.Profiles)
+	c.json.Profiles = resolved
+	if err := c.WriteConduitJsonFile(); err != nil {
+		return nil, nil, err
+	}
+	//reload so the services of the new profiles are enabled
+	next, err := c.reload(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(services) > 0 {
+		if err := next.Create(ctx, services); err != nil {
+			return nil, nil, err
+		}
+		if err := next.Start(ctx, services); err != nil {
+			return nil, nil, err
+		}
+	}
+	active, _ = next.Modules()
+	return active, required, nil
+}
+
+/*
+Stops and removes the services of the modules then removes them from conduit.json.
+Modules an active module depends on can not be disabled on their own and
+services another active profile still needs are left running. Returns the modules that are active afterwards
+*/
+func (c *Conduit) DisableModules(ctx context.Context, modules []string) ([]string, error) {
+	active, err := c.checkModules(modules)
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, module := range modules {
+		if slices.Contains(active, module) && !slices.Contains(removed, module) {
+			removed = append(removed, module)
+		}
+	}
+	if len(removed) == 0 {
+		return active, nil
+	}
+	remaining := []string{}
+	for _, profile := range c.json.Profiles {
+		if !slices.Contains(removed, profile) {
+			remaining = append(remaining, profile)
+		}
+	}
+	//a module that is still required would be enabled again by the next resolution
+	_, required, err := c.composer.ResolveProfiles(remaining)
+	if err != nil {
+		return nil, err
+	}
+	for _, module := range removed {
+		if requiredBy, ok := required[module]; ok {
+			return nil, fmt.Errorf("%s is required by %v disable them as well", module, requiredBy)
+		}
+	}
+	if services := exclusiveServices(c, removed, remaining); len(services) > 0 {
+		if err := c.Remove(ctx, services); err != nil {
+			return nil, err
+		}
+	}
+	c.json.Profiles = remaining
+	if err := c.WriteConduitJsonFile(); err != nil {
+		return nil, err
+	}
+	active, _ = c.Modules()
+	return active, nil
+}
+
+// Errors when one of the names is a database or not a module of the compose file, returns the active modules
+func (c *Conduit) checkModules(modules []string) ([]string, error) {
+	active, available := c.Modules()
+	unknown := []string{}
+	for _, module := range modules {
+		if module == "mongodb" || module == "postgres" {
+			return nil, fmt.Errorf("%s is a database not a module, the database of a project can not be changed", module)
+		}
+		if !slices.Contains(available, module) {
+			unknown = append(unknown, module)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown modules %v available modules are %v", unknown, available)
+	}
+	return active, nil
+}
+
+// Returns the services of the profiles that none of the other profiles enable
+func exclusiveServices(c *Conduit, profiles, others []string) []string {
+	shared := c.composer.ProfileServices(others...)
+	services := []string{}
+	for _, service := range c.composer.ProfileServices(profiles...) {
+		if !slices.Contains(shared, service) {
+			services = append(services, service)
+		}
+	}
+	return services
+}
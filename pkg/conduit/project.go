@@ -0,0 +1,492 @@
+package conduit
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+	"github.com/isolateminds/go-conduit-cli/internal/docker"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Bumped whenever the layout of the project archive changes
+	projectArchiveFormatVersion = 1
+
+	projectArchiveManifestFile = "manifest.json"
+	projectArchiveFilesDir     = "project"
+	projectArchiveVolumesDir   = "volumes"
+)
+
+// The project files that are exported, missing ones are skipped
+var projectFiles = []string{"conduit.json", "docker-compose.yaml", composeopt.DefaultOverrideFile, ".env", ".gitignore", "loki.cfg.yml", "prometheus.cfg.yml"}
+
+// Stored as the first file of every project archive
+type ProjectArchiveManifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	ProjectName   string    `json:"projectName"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Files         []string  `json:"files"`
+	// Keyed by the volume name in the compose file, the ./database bind mount is stored as database
+	Volumes map[string]string `json:"volumes"`
+}
+
+/*
+Writes a gzipped tar archive to dst with the project files and a tarred copy of every project volume.
+The running services are stopped while the volumes are copied and started again afterwards
+*/
+func (c *Conduit) Export(ctx context.Context, dst string) (err error) {
+	statuses, err := c.Status(ctx)
+	if err != nil {
+		return err
+	}
+	running := []string{}
+	for _, s := range statuses {
+		if s.State == "running" {
+			running = append(running, s.Service)
+		}
+	}
+	if len(running) > 0 {
+		if err := c.Stop(ctx, running); err != nil {
+			return err
+		}
+		defer func() {
+			if startErr := c.Start(ctx, running); startErr != nil && err == nil {
+				err = startErr
+			}
+		}()
+	}
+
+	tmp, err := os.MkdirTemp("", "goconduit-export-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	manifest := &ProjectArchiveManifest{
+		FormatVersion: projectArchiveFormatVersion,
+		ProjectName:   c.json.ProjectName,
+		CreatedAt:     time.Now().UTC(),
+		Files:         []string{},
+		Volumes:       map[string]string{},
+	}
+	for _, file := range projectFiles {
+		if _, err := os.Stat(file); err == nil {
+			manifest.Files = append(manifest.Files, file)
+		}
+	}
+	for key, storage := range c.exportableStorage(ctx) {
+		archive := key + ".tar.gz"
+		err := c.runHelperContainer(ctx,
+			[]types.Mount{
+				{Type: storage.Type, Source: storage.Source, Target: "/data"},
+				{Type: StorageBind, Source: tmp, Target: "/backup"},
+			},
+			"tar", "czf", "/backup/"+archive, "-C", "/data", ".",
+		)
+		if err != nil {
+			return fmt.Errorf("exporting volume %s: %s", key, err)
+		}
+		manifest.Volumes[key] = path.Join(projectArchiveVolumesDir, archive)
+	}
+	return writeProjectArchive(dst, manifest, tmp)
+}
+
+// Returns the volumes that exist and the ./database bind mount if the database uses one
+func (c *Conduit) exportableStorage(ctx context.Context) map[string]types.Mount {
+	storage := map[string]types.Mount{}
+	for key, name := range c.composer.Volumes() {
+		if exists, err := c.client.VolumeExists(ctx, c.client.NewVolume(name)); err == nil && exists {
+			storage[key] = types.Mount{Type: StorageVolume, Source: name}
+		}
+	}
+	if mount, err := c.databaseMount(); err == nil && mount.Type == StorageBind {
+		storage[databaseBindDir] = mount
+	}
+	return storage
+}
+
+func writeProjectArchive(dst string, manifest *ProjectArchiveManifest, volumesDir string) (err error) {
+	meta, err := json.MarshalIndent(manifest, "", "	")
+	if err != nil {
+		return err
+	}
+	partial := dst + ".partial"
+	out, err := os.Create(partial)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(partial)
+		}
+	}()
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	if err = writeTarFile(tw, projectArchiveManifestFile, int64(len(meta)), manifest.CreatedAt, strings.NewReader(string(meta))); err != nil {
+		return err
+	}
+	for _, file := range manifest.Files {
+		if err = addFileToTar(tw, file, path.Join(projectArchiveFilesDir, file)); err != nil {
+			return err
+		}
+	}
+	keys := []string{}
+	for key := range manifest.Volumes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err = addFileToTar(tw, filepath.Join(volumesDir, path.Base(manifest.Volumes[key])), manifest.Volumes[key]); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partial, dst)
+}
+
+func addFileToTar(tw *tar.Writer, src, name string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return writeTarFile(tw, name, info.Size(), info.ModTime(), file)
+}
+
+// Reads the manifest of a project archive
+func ReadProjectArchiveManifest(src string) (*ProjectArchiveManifest, error) {
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a project archive: %s", src, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	header, err := tr.Next()
+	if err != nil || header.Name != projectArchiveManifestFile {
+		return nil, fmt.Errorf("%s is not a project archive: missing %s", src, projectArchiveManifestFile)
+	}
+	manifest := &ProjectArchiveManifest{}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("invalid project archive manifest: %s", err)
+	}
+	if manifest.FormatVersion != projectArchiveFormatVersion {
+		return nil, fmt.Errorf("unsupported project archive format version %d", manifest.FormatVersion)
+	}
+	return manifest, nil
+}
+
+/*
+Returns the name or the first of name-2, name-3... that has no directory in the current path
+and none of the volumes in use by another project
+*/
+func AvailableProjectName(ctx context.Context, name string, manifest *ProjectArchiveManifest) (string, error) {
+	client, err := docker.NewClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	taken := func(candidate string) (bool, error) {
+		if _, err := os.Stat(candidate); err == nil {
+			return true, nil
+		}
+		for key := range manifest.Volumes {
+			if key == databaseBindDir {
+				continue
+			}
+			exists, err := client.VolumeExists(ctx, client.NewVolume(fmt.Sprintf("%s_%s", candidate, key)))
+			if err != nil || exists {
+				return true, err
+			}
+		}
+		return false, nil
+	}
+	candidate := name
+	for i := 2; ; i++ {
+		isTaken, err := taken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !isTaken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+/*
+Recreates a project from an archive created with Export, must be called from the empty project directory.
+The project files are written with the project name remapped, the containers and volumes are created
+and the volumes are filled with the exported data. Nothing is started
+*/
+func ImportProject(ctx context.Context, src, projectName string) (_ *Conduit, err error) {
+	manifest, err := ReadProjectArchiveManifest(src)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "goconduit-import-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	if err := extractProjectArchive(src, manifest, tmp); err != nil {
+		return nil, err
+	}
+	if err := renameProject(projectName); err != nil {
+		return nil, err
+	}
+	con, err := NewConduitFromProject(ctx, true, []string{})
+	if err != nil {
+		return nil, err
+	}
+	//volumes that already exist are reused by compose and must survive a failed import
+	existing := map[string]bool{}
+	for _, name := range con.composer.Volumes() {
+		exists, err := con.client.VolumeExists(ctx, con.client.NewVolume(name))
+		if err != nil {
+			return nil, err
+		}
+		existing[name] = exists
+	}
+	defer func() {
+		if err != nil {
+			con.removeImported(ctx, existing)
+		}
+	}()
+	if _, ok := manifest.Volumes[databaseBindDir]; ok {
+		if err := os.MkdirAll(databaseBindDir, fs.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	//creates the volumes along with the containers
+	if err := con.Create(ctx, []string{}); err != nil {
+		return nil, err
+	}
+	volumes := con.composer.Volumes()
+	for key, archive := range manifest.Volumes {
+		storage := types.Mount{Type: StorageVolume, Source: volumes[key]}
+		if key == databaseBindDir {
+			abs, err := filepath.Abs(databaseBindDir)
+			if err != nil {
+				return nil, err
+			}
+			storage = types.Mount{Type: StorageBind, Source: abs}
+		} else if storage.Source == "" {
+			return nil, fmt.Errorf("volume %s is not defined in docker-compose.yaml", key)
+		}
+		err := con.runHelperContainer(ctx,
+			[]types.Mount{
+				{Type: storage.Type, Source: storage.Source, Target: "/data"},
+				{Type: StorageBind, Source: tmp, Target: "/backup"},
+			},
+			"tar", "xzf", "/backup/"+path.Base(archive), "-C", "/data",
+		)
+		if err != nil {
+			return nil, fmt.Errorf("importing volume %s: %s", key, err)
+		}
+	}
+	return con, nil
+}
+
+// Removes the containers and the volumes created by a failed import, best effort since the import already failed
+func (c *Conduit) removeImported(ctx context.Context, existing map[string]bool) {
+	c.Remove(ctx, []string{})
+	for _, name := range c.composer.Volumes() {
+		if !existing[name] {
+			c.client.RemoveVolume(ctx, c.client.NewVolume(name), true)
+		}
+	}
+}
+
+// Writes the project files to the current path and the volume archives to volumesDir
+func extractProjectArchive(src string, manifest *ProjectArchiveManifest, volumesDir string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	volumeArchives := []string{}
+	for _, archive := range manifest.Volumes {
+		volumeArchives = append(volumeArchives, archive)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var dst string
+		switch {
+		case header.Name == projectArchiveManifestFile:
+			continue
+		//only the files listed in the manifest are extracted so nothing can be written outside of the project
+		case strings.HasPrefix(header.Name, projectArchiveFilesDir+"/") && slices.Contains(projectFiles, strings.TrimPrefix(header.Name, projectArchiveFilesDir+"/")):
+			dst = strings.TrimPrefix(header.Name, projectArchiveFilesDir+"/")
+		case slices.Contains(volumeArchives, header.Name):
+			dst = filepath.Join(volumesDir, path.Base(header.Name))
+		default:
+			return fmt.Errorf("unexpected file %s in project archive", header.Name)
+		}
+		if err := extractTarFile(tr, dst); err != nil {
+			return err
+		}
+	}
+}
+
+func extractTarFile(r io.Reader, dst string) error {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Sets the project name in conduit.json and .env, a rollback, the extra compose files and what a manifest applied
+// are dropped because the snapshot, the files and the values from before the manifest are not part of the archive.
+// When the name changes the fixed container and network names of the compose files are remapped too
+func renameProject(projectName string) error {
+	b, err := os.ReadFile("conduit.json")
+	if err != nil {
+		return err
+	}
+	data := &ConduitJson{}
+	if err := json.Unmarshal(b, data); err != nil {
+		return err
+	}
+	if data.ProjectName != projectName {
+		for _, file := range []string{"docker-compose.yaml", composeopt.DefaultOverrideFile} {
+			if err := remapComposeFile(file, projectName); err != nil {
+				return err
+			}
+		}
+	}
+	data.ProjectName = projectName
+	data.Rollback = nil
+	data.ComposeFiles = nil
+	data.Applied = nil
+	if err := data.WriteFile(); err != nil {
+		return err
+	}
+	env, err := types.NewEnvFromFile(".env")
+	if err != nil {
+		return err
+	}
+	env.Set("COMPOSE_PROJECT_NAME", projectName)
+	return os.WriteFile(".env", env.Bytes, fs.ModePerm)
+}
+
+func remapComposeFile(file, projectName string) error {
+	b, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b, err = remapComposeNames(b, projectName)
+	if err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+	return os.WriteFile(file, b, fs.ModePerm)
+}
+
+/*
+Drops the container_name of every service so the containers of the project do not collide with the ones
+of the exported project, the old name becomes a network alias so the services still reach each other by it.
+Networks with a fixed name are renamed after the project unless they are external
+*/
+func remapComposeNames(in []byte, projectName string) ([]byte, error) {
+	doc, root, err := decodeCompose(in)
+	if err != nil {
+		return nil, err
+	}
+	services := mappingValue(root, "services")
+	for _, name := range mappingKeys(services) {
+		service := mappingValue(services, name)
+		containerName := deleteMappingKey(service, "container_name")
+		if containerName == nil || containerName.Value == "" {
+			continue
+		}
+		networks := mappingValue(service, "networks")
+		if networks != nil && networks.Kind == yaml.SequenceNode {
+			//the short syntax can not hold aliases so it is turned into the long one
+			keys := networks.Content
+			networks.Kind, networks.Tag, networks.Style, networks.Content = yaml.MappingNode, "!!map", 0, nil
+			for _, key := range keys {
+				networks.Content = append(networks.Content, key, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+			}
+		}
+		if networks == nil || len(networks.Content) == 0 {
+			networks = ensureMappingValue(service, "networks")
+			ensureMapping(networks, "default")
+		}
+		for _, network := range mappingKeys(networks) {
+			aliases := mappingValue(ensureMappingValue(networks, network), "aliases")
+			if aliases == nil {
+				aliases = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+				mappingValue(networks, network).Content = append(mappingValue(networks, network).Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "aliases"}, aliases)
+			}
+			appendUniqueScalar(aliases, containerName.Value)
+		}
+	}
+	networks := mappingValue(root, "networks")
+	for _, key := range mappingKeys(networks) {
+		network := mappingValue(networks, key)
+		name := mappingValue(network, "name")
+		if name == nil {
+			continue
+		}
+		if external := mappingValue(network, "external"); external != nil && external.Value != "false" {
+			continue
+		}
+		name.Value = projectName
+		if key != "default" {
+			name.Value = fmt.Sprintf("%s_%s", projectName, key)
+		}
+	}
+	return encodeCompose(doc)
+}
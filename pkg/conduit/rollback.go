@@ -0,0 +1,246 @@
+package conduit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// Where database snapshots are written relative to the project root
+	backupsDir = "backups"
+	// The copy of the .env taken before an update, kept in the backups directory
+	rollbackEnvFile = "rollback.env"
+)
+
+// Creates the backups directory with a .gitignore so the snapshots and .env copies it holds are never committed.
+// Returns its absolute path
+func ensureBackupsDir() (string, error) {
+	dir, err := filepath.Abs(backupsDir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, fs.ModePerm); err != nil {
+		return "", err
+	}
+	gitignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(gitignore); errors.Is(err, os.ErrNotExist) {
+		return dir, os.WriteFile(gitignore, []byte("*\n"), fs.ModePerm)
+	}
+	return dir, nil
+}
+
+// Reports whether the project was setup with a database
+func (c *Conduit) HasDatabase() bool {
+	return c.json.Database != ""
+}
+
+// Returns the storage of the database service either its named volume or bind mount
+func (c *Conduit) databaseMount() (types.Mount, error) {
+	if !c.HasDatabase() {
+		return types.Mount{}, errors.New("the project has no database")
+	}
+	mounts, err := c.composer.ServiceMounts(c.json.Database)
+	if err != nil {
+		return types.Mount{}, err
+	}
+	if len(mounts) == 0 {
+		return types.Mount{}, fmt.Errorf("the %s service has no volume or bind mount", c.json.Database)
+	}
+	return mounts[0], nil
+}
+
+/*
+Stops the database service, archives its storage (named volume or ./database bind mount)
+to the backups directory and starts it again even when archiving fails. Returns the path of the archive
+*/
+func (c *Conduit) SnapshotDatabase(ctx context.Context, name string) (snapshot string, err error) {
+	storage, err := c.databaseMount()
+	if err != nil {
+		return "", err
+	}
+	dir, err := ensureBackupsDir()
+	if err != nil {
+		return "", err
+	}
+	if err := c.Stop(ctx, []string{c.json.Database}); err != nil {
+		return "", err
+	}
+	defer func() {
+		if startErr := c.Start(ctx, []string{c.json.Database}); startErr != nil && err == nil {
+			snapshot, err = "", startErr
+		}
+	}()
+	archive := fmt.Sprintf("%s.tar.gz", name)
+	err = c.runHelperContainer(ctx,
+		[]types.Mount{
+			{Type: storage.Type, Source: storage.Source, Target: "/data"},
+			{Type: "bind", Source: dir, Target: "/backup"},
+		},
+		"tar", "czf", "/backup/"+archive, "-C", "/data", ".",
+	)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(backupsDir, archive), nil
+}
+
+/*
+Extracts the archive next to the database files first so a truncated or corrupt archive fails before anything is
+deleted, the extracted files are then moved in place on the same filesystem
+*/
+const restoreStorageScript = `set -e
+staging=/data/.goconduit-restore
+rm -rf "$staging"
+mkdir "$staging"
+if ! tar xzf "/backup/$1" -C "$staging"; then
+	rm -rf "$staging"
+	exit 1
+fi
+find /data -mindepth 1 -maxdepth 1 ! -name .goconduit-restore -exec rm -rf {} +
+find "$staging" -mindepth 1 -maxdepth 1 -exec mv {} /data/ \;
+rmdir "$staging"
+`
+
+// Replaces the contents of the database storage with the archive, the database service must be stopped
+func (c *Conduit) RestoreDatabase(ctx context.Context, src string) error {
+	storage, err := c.databaseMount()
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return err
+	}
+	return c.runHelperContainer(ctx,
+		[]types.Mount{
+			{Type: storage.Type, Source: storage.Source, Target: "/data"},
+			{Type: "bind", Source: filepath.Dir(abs), Target: "/backup"},
+		},
+		"sh", "-c", restoreStorageScript, "sh", filepath.Base(abs),
+	)
+}
+
+// Records the current state so the next update can be undone with Rollback.
+// The .env holds secrets so it is copied to the gitignored backups directory instead of conduit.json
+func (c *Conduit) SetRollback(snapshot string) error {
+	dir, err := ensureBackupsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, rollbackEnvFile), c.composer.Options.Environment.Bytes, fs.ModePerm); err != nil {
+		return err
+	}
+	imageTag, uiImageTag := c.ImageTags()
+	c.json.Rollback = &RollbackJson{
+		Version:    c.json.Version,
+		ImageTag:   imageTag,
+		UIImageTag: uiImageTag,
+		EnvFile:    filepath.Join(backupsDir, rollbackEnvFile),
+		Snapshot:   snapshot,
+		CreatedAt:  time.Now(),
+	}
+	return nil
+}
+
+// Returns the recorded rollback or nil if there is none
+func (c *Conduit) GetRollback() *RollbackJson {
+	return c.json.Rollback
+}
+
+/*
+Undoes the last update: stops the project, restores the database snapshot, the previous .env
+and version then recreates every service. Returns the reloaded project
+*/
+func (c *Conduit) Rollback(ctx context.Context) (*Conduit, error) {
+	rollback := c.json.Rollback
+	if rollback == nil {
+		return nil, errors.New("there is no update to roll back")
+	}
+	env, err := os.ReadFile(rollback.EnvFile)
+	if err != nil {
+		return nil, fmt.Errorf("the .env from before the update can not be read: %s", err)
+	}
+	if err := c.Stop(ctx, []string{}); err != nil {
+		return nil, err
+	}
+	if rollback.Snapshot != "" {
+		if err := c.RestoreDatabase(ctx, rollback.Snapshot); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(".env", env, fs.ModePerm); err != nil {
+		return nil, err
+	}
+	c.json.Version = rollback.Version
+	c.json.Rollback = nil
+	if err := c.WriteConduitJsonFile(); err != nil {
+		return nil, err
+	}
+	os.Remove(rollback.EnvFile)
+	//reload so the previous tags are interpolated
+	previous, err := c.reload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := previous.Create(ctx, []string{}); err != nil {
+		return nil, err
+	}
+	if err := previous.Start(ctx, []string{}); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+/*
+Waits until the services are running and healthy (or have no health check) and stay that way
+for the settle duration. Returns an error listing the unhealthy services once the timeout is reached
+*/
+func (c *Conduit) WaitHealthy(ctx context.Context, services []string, timeout, settle time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var healthySince time.Time
+	for {
+		statuses, err := c.Status(ctx)
+		if err != nil {
+			return err
+		}
+		unhealthy := []string{}
+		for _, s := range statuses {
+			if !slices.Contains(services, s.Service) {
+				continue
+			}
+			if s.State != "running" || (s.Health != "" && s.Health != "healthy") {
+				unhealthy = append(unhealthy, s.Service)
+			}
+		}
+		switch {
+		case len(unhealthy) > 0:
+			healthySince = time.Time{}
+		case healthySince.IsZero():
+			healthySince = time.Now()
+		case time.Since(healthySince) >= settle:
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if len(unhealthy) == 0 {
+				return nil
+			}
+			return fmt.Errorf("%v did not become healthy within %s", unhealthy, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package conduit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// When the next run of a schedule is due
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+// Runs at the start of every period in local time eg: every day at midnight
+type truncatedSchedule struct {
+	period time.Duration
+}
+
+func (s truncatedSchedule) Next(after time.Time) time.Time {
+	switch s.period {
+	case time.Hour:
+		return after.Truncate(time.Hour).Add(time.Hour)
+	case 7 * 24 * time.Hour:
+		midnight := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location())
+		//weeks start on sunday like cron
+		return midnight.AddDate(0, 0, 7-int(midnight.Weekday()))
+	default:
+		midnight := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location())
+		return midnight.AddDate(0, 0, 1)
+	}
+}
+
+/*
+Parses a schedule in one of the forms
+
+	@every 6h    every 6 hours from now, any go duration of at least a minute
+	@hourly      at the start of every hour
+	@daily       every day at midnight (also @midnight)
+	@weekly      every sunday at midnight
+*/
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		return truncatedSchedule{period: time.Hour}, nil
+	case "@daily", "@midnight":
+		return truncatedSchedule{period: 24 * time.Hour}, nil
+	case "@weekly":
+		return truncatedSchedule{period: 7 * 24 * time.Hour}, nil
+	}
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s", spec, err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("invalid schedule %q: the interval must be at least 1m", spec)
+		}
+		return everySchedule{interval: d}, nil
+	}
+	return nil, fmt.Errorf("invalid schedule %q use @every <duration>, @hourly, @daily or @weekly", spec)
+}
@@ -0,0 +1,196 @@
+package conduit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
+	"github.com/isolateminds/go-conduit-cli/internal/compose/types"
+)
+
+const (
+	StorageVolume = "volume"
+	StorageBind   = "bind"
+
+	// Where the database is bind mounted relative to the project root
+	databaseBindDir = "database"
+)
+
+func bootstrapStorage(db string, mountDatabase bool) string {
+	switch {
+	case db == "":
+		return ""
+	case mountDatabase:
+		return StorageBind
+	default:
+		return StorageVolume
+	}
+}
+
+// Returns the storage the database currently uses either volume or bind
+func (c *Conduit) Storage() (string, error) {
+	storage, err := c.databaseMount()
+	if err != nil {
+		return "", err
+	}
+	return storage.Type, nil
+}
+
+/*
+Moves the database between its named volume and the ./database bind mount.
+The database service is stopped, the data is copied through a helper container and the copy is verified
+by comparing file counts and sizes before the compose file and conduit.json are rewritten and the source is deleted.
+When anything fails before the source is deleted the previous compose file is restored and the database restarted
+*/
+func (c *Conduit) MigrateStorage(ctx context.Context, to string) error {
+	if to != StorageVolume && to != StorageBind {
+		return fmt.Errorf("invalid storage %s use %s or %s", to, StorageVolume, StorageBind)
+	}
+	db := c.json.Database
+	source, err := c.databaseMount()
+	if err != nil {
+		return err
+	}
+	if source.Type == to {
+		return fmt.Errorf("the %s service already uses %s storage", db, to)
+	}
+	var formatter composeopt.YamlFormatter = newComposeBindDbFormatter(db)
+	if to == StorageVolume {
+		formatter = newComposeVolumeDbFormatter(db)
+	}
+	original := c.composer.Options.Yaml.Bytes
+	migrated, err := formatter.Format(original)
+	if err != nil {
+		return err
+	}
+	if to == StorageBind {
+		if err := os.MkdirAll(databaseBindDir, fs.ModePerm); err != nil {
+			return err
+		}
+	}
+	if err := c.Stop(ctx, []string{db}); err != nil {
+		return err
+	}
+
+	//puts the previous compose file back and restarts the database on its old storage
+	restore := func(cause error) error {
+		if err := os.WriteFile("docker-compose.yaml", original, fs.ModePerm); err != nil {
+			return fmt.Errorf("%s (restoring docker-compose.yaml failed: %s)", cause, err)
+		}
+		previous, err := c.reload(ctx)
+		if err == nil {
+			err = previous.Create(ctx, []string{db})
+		}
+		if err == nil {
+			err = previous.Start(ctx, []string{db})
+		}
+		if err != nil {
+			return fmt.Errorf("%s (restarting %s failed: %s)", cause, db, err)
+		}
+		return cause
+	}
+
+	if err := os.WriteFile("docker-compose.yaml", migrated, fs.ModePerm); err != nil {
+		return restore(err)
+	}
+	next, err := c.reload(ctx)
+	if err != nil {
+		return restore(err)
+	}
+	//recreates the container on the new storage and creates the named volume if needed
+	if err := next.Create(ctx, []string{db}); err != nil {
+		return restore(err)
+	}
+	destination, err := next.databaseMount()
+	if err != nil {
+		return restore(err)
+	}
+	if err := c.copyStorage(ctx, source, destination); err != nil {
+		return restore(err)
+	}
+	next.json.Storage = to
+	if err := next.WriteConduitJsonFile(); err != nil {
+		return restore(err)
+	}
+	if err := next.Start(ctx, []string{db}); err != nil {
+		return err
+	}
+	return c.deleteStorage(ctx, source)
+}
+
+// Copies the data keeping ownership and permissions and verifies the copy, the destination must be empty
+func (c *Conduit) copyStorage(ctx context.Context, source, destination types.Mount) error {
+	count, _, err := c.storageUsage(ctx, destination)
+	if err != nil {
+		return err
+	}
+	//the count includes the root directory
+	if count > 1 {
+		return fmt.Errorf("%s is not empty", destination.Source)
+	}
+	err = c.runHelperContainer(ctx,
+		[]types.Mount{
+			{Type: source.Type, Source: source.Source, Target: "/from"},
+			{Type: destination.Type, Source: destination.Source, Target: "/to"},
+		},
+		"cp", "-a", "/from/.", "/to/",
+	)
+	if err != nil {
+		return err
+	}
+	srcCount, srcSize, err := c.storageUsage(ctx, source)
+	if err != nil {
+		return err
+	}
+	dstCount, dstSize, err := c.storageUsage(ctx, destination)
+	if err != nil {
+		return err
+	}
+	if srcCount != dstCount || srcSize != dstSize {
+		return fmt.Errorf("copy verification failed: %s has %d files (%d bytes) but %s has %d files (%d bytes)",
+			source.Source, srcCount, srcSize, destination.Source, dstCount, dstSize)
+	}
+	return nil
+}
+
+// Returns the number of entries and the total size of the regular files
+func (c *Conduit) storageUsage(ctx context.Context, storage types.Mount) (count, size int64, err error) {
+	out, err := c.runHelperContainerOutput(ctx,
+		[]types.Mount{{Type: storage.Type, Source: storage.Source, Target: "/data"}},
+		"sh", "-c", `cd /data && echo "$(find . | wc -l) $(find . -type f -exec stat -c %s {} + | awk '{s+=$1} END {print s+0}')"`,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscan(out, &count, &size); err != nil {
+		return 0, 0, fmt.Errorf("unexpected storage usage output %q", out)
+	}
+	return count, size, nil
+}
+
+// Deletes the named volume or the contents of the bind mount and its directory
+func (c *Conduit) deleteStorage(ctx context.Context, storage types.Mount) error {
+	switch storage.Type {
+	case StorageVolume:
+		return c.client.RemoveVolume(ctx, c.client.NewVolume(storage.Source), false)
+	case StorageBind:
+		//the files are owned by the database user so they are deleted from inside a container
+		err := c.runHelperContainer(ctx,
+			[]types.Mount{{Type: storage.Type, Source: storage.Source, Target: "/data"}},
+			"find", "/data", "-mindepth", "1", "-delete",
+		)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(storage.Source); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("could not remove %s: %s", filepath.Base(storage.Source), err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown storage type %s", storage.Type)
+	}
+}
@@ -0,0 +1,239 @@
+package conduit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/isolateminds/go-conduit-cli/internal/compose/composeopt"
+	"github.com/isolateminds/go-conduit-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// The values available to the env and compose templates eg: {{ .ProjectName }}
+type templateData map[string]interface{}
+
+/*
+Renders the env and compose templates with text/template. Besides the builtin functions the templates can use
+
+	{{ randomSecret 32 }}             a random alphanumeric string of the given length
+	{{ default "latest" .ImageTag }}  the value or the default when the value is empty or missing
+	{{ required .ProjectName }}       the value or an error when it is empty
+*/
+type templateEngine struct {
+	data templateData
+}
+
+func newTemplateEngine(data templateData) *templateEngine {
+	return &templateEngine{data: data}
+}
+
+func (e *templateEngine) funcs() template.FuncMap {
+	return template.FuncMap{
+		"randomSecret": func(length int) (string, error) {
+			if length <= 0 {
+				return "", fmt.Errorf("randomSecret length must be positive got %d", length)
+			}
+			return utils.GenerateRandomString(length), nil
+		},
+		"default": func(def string, value ...interface{}) string {
+			if len(value) == 0 || value[0] == nil || fmt.Sprint(value[0]) == "" {
+				return def
+			}
+			return fmt.Sprint(value[0])
+		},
+		"required": func(value interface{}) (string, error) {
+			if value == nil || fmt.Sprint(value) == "" {
+				return "", errors.New("a required value is empty")
+			}
+			return fmt.Sprint(value), nil
+		},
+	}
+}
+
+// Renders the template, every unresolved placeholder is listed in the error
+func (e *templateEngine) Render(name string, in []byte) ([]byte, error) {
+	//missing keys are reported by unresolved so default can be given missing values
+	t, err := template.New(name).Funcs(e.funcs()).Parse(string(e.upgradeLegacyPlaceholders(in)))
+	if err != nil {
+		if strings.Contains(err.Error(), "not defined") {
+			return nil, fmt.Errorf("%s (placeholders are written as {{ .Name }})", err)
+		}
+		return nil, err
+	}
+	if missing := e.unresolved(t); len(missing) > 0 {
+		return nil, fmt.Errorf("%s has unresolved placeholders: %s", name, strings.Join(missing, ", "))
+	}
+	out := &bytes.Buffer{}
+	if err := t.Execute(out, e.data); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Matches the placeholders of the templates from before text/template eg: {{ImageTag}}
+var legacyPlaceholderRe = regexp.MustCompile(`\{\{([A-Za-z][A-Za-z0-9]*)\}\}`)
+
+// Rewrites the old {{Key}} placeholders of keys in the data to {{ .Key }} so older templates still render
+func (e *templateEngine) upgradeLegacyPlaceholders(in []byte) []byte {
+	return legacyPlaceholderRe.ReplaceAllFunc(in, func(match []byte) []byte {
+		key := string(legacyPlaceholderRe.FindSubmatch(match)[1])
+		if _, ok := e.data[key]; !ok {
+			return match
+		}
+		return []byte("{{ ." + key + " }}")
+	})
+}
+
+// The env templates of each database from before they were merged into conduit.env
+var legacyEnvTemplates = map[string]string{
+	"mongodb":  "mongo.env",
+	"postgres": "postgres.env",
+}
+
+// Falls back to the env template of the database when the source still has the layout from before conduit.env
+type legacyEnvTemplateSource struct {
+	composeopt.TemplateSource
+	database string
+}
+
+func (s *legacyEnvTemplateSource) ReadTemplate(name string) ([]byte, error) {
+	b, err := s.TemplateSource.ReadTemplate(name)
+	legacy, ok := legacyEnvTemplates[s.database]
+	if err == nil || name != envTemplate || !ok || !composeopt.IsTemplateNotExist(err) {
+		return b, err
+	}
+	b, legacyErr := s.TemplateSource.ReadTemplate(legacy)
+	if legacyErr != nil {
+		if composeopt.IsTemplateNotExist(legacyErr) {
+			return nil, fmt.Errorf("%s has neither %s nor %s from older releases: %s", s.TemplateSource, envTemplate, legacy, err)
+		}
+		return nil, fmt.Errorf("%s has no %s, reading %s from older releases failed: %s", s.TemplateSource, envTemplate, legacy, legacyErr)
+	}
+	return b, nil
+}
+
+func newLegacyEnvTemplateSource(src composeopt.TemplateSource, database string) *legacyEnvTemplateSource {
+	return &legacyEnvTemplateSource{TemplateSource: src, database: database}
+}
+
+// Returns the sorted top level fields eg: {{ .Typo }} that are not in the data
+func (e *templateEngine) unresolved(t *template.Template) []string {
+	fields := map[string]struct{}{}
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree != nil {
+			collectFields(tmpl.Tree.Root, fields)
+		}
+	}
+	missing := []string{}
+	for field := range fields {
+		if _, ok := e.data[field]; !ok {
+			missing = append(missing, "{{ ."+field+" }}")
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+/*
+Walks the parse tree collecting fields evaluated against the root data.
+The bodies of range and with are skipped because the dot changes there and
+arguments of default are skipped because they are allowed to be missing
+*/
+func collectFields(node parse.Node, fields map[string]struct{}) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFields(child, fields)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, fields)
+	case *parse.IfNode:
+		collectFields(n.Pipe, fields)
+		collectFields(n.List, fields)
+		collectFields(n.ElseList, fields)
+	case *parse.RangeNode:
+		collectFields(n.Pipe, fields)
+		collectFields(n.ElseList, fields)
+	case *parse.WithNode:
+		collectFields(n.Pipe, fields)
+		collectFields(n.ElseList, fields)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, fields)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectFields(cmd, fields)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) > 0 {
+			if ident, ok := n.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "default" {
+				return
+			}
+		}
+		for _, arg := range n.Args {
+			collectFields(arg, fields)
+		}
+	case *parse.FieldNode:
+		fields[n.Ident[0]] = struct{}{}
+	case *parse.ChainNode:
+		collectFields(n.Node, fields)
+	}
+}
+
+/*
+Returns the profiles of the compose template sorted by name split into the database profiles
+and the optional module profiles. The template is rendered with placeholder values first
+*/
+func TemplateProfiles(src composeopt.TemplateSource) (databases, modules []string, err error) {
+	b, err := src.ReadTemplate(dockerComposeTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+	rendered, err := newTemplateEngine(templateData{
+		"Database":    "",
+		"ImageTag":    "latest",
+		"UIImageTag":  "latest",
+		"ProjectName": "conduit",
+		"Profiles":    []string{},
+	}).Render(dockerComposeTemplate, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	compose := struct {
+		Services map[string]struct {
+			Profiles []string `yaml:"profiles"`
+		} `yaml:"services"`
+	}{}
+	if err := yaml.Unmarshal(rendered, &compose); err != nil {
+		return nil, nil, err
+	}
+	seen := map[string]struct{}{}
+	databases, modules = []string{}, []string{}
+	for _, service := range compose.Services {
+		for _, profile := range service.Profiles {
+			if _, ok := seen[profile]; ok {
+				continue
+			}
+			seen[profile] = struct{}{}
+			if profile == "mongodb" || profile == "postgres" {
+				databases = append(databases, profile)
+			} else {
+				modules = append(modules, profile)
+			}
+		}
+	}
+	sort.Strings(databases)
+	sort.Strings(modules)
+	return databases, modules, nil
+}
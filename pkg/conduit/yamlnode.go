@@ -0,0 +1,155 @@
+package conduit
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Helpers for editing a yaml.v3 node tree in place so comments, ordering, quoting and unknown keys survive
+
+// Returns the value of the key in a mapping node or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Removes the key and its value from a mapping node, returns the removed value or nil
+func deleteMappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value := node.Content[i+1]
+			//a comment above the first key usually describes the whole mapping so it moves to the new first key
+			if i == 0 && i+2 < len(node.Content) && node.Content[i+2].HeadComment == "" {
+				node.Content[i+2].HeadComment = node.Content[i].HeadComment
+			}
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return value
+		}
+	}
+	return nil
+}
+
+// Returns the keys of a mapping node in document order
+func mappingKeys(node *yaml.Node) []string {
+	keys := []string{}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return keys
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys
+}
+
+// Returns the source of a service volume entry in either the short syntax eg: mongo:/data/db
+// or the long syntax where it is the value of the source key
+func volumeSource(entry *yaml.Node) string {
+	switch entry.Kind {
+	case yaml.ScalarNode:
+		if source, _, ok := strings.Cut(entry.Value, ":"); ok {
+			return source
+		}
+	case yaml.MappingNode:
+		if source := mappingValue(entry, "source"); source != nil {
+			return source.Value
+		}
+	}
+	return ""
+}
+
+// Points a service volume entry at a bind mount keeping its target and options
+func setBindSource(entry *yaml.Node, source string) {
+	setVolumeSource(entry, source, "bind")
+	//volume options are invalid on bind mounts
+	deleteMappingKey(entry, "volume")
+}
+
+// Points a service volume entry at a named volume keeping its target and options
+func setNamedVolumeSource(entry *yaml.Node, volume string) {
+	setVolumeSource(entry, volume, "volume")
+	deleteMappingKey(entry, "bind")
+}
+
+func setVolumeSource(entry *yaml.Node, source, kind string) {
+	switch entry.Kind {
+	case yaml.ScalarNode:
+		_, rest, _ := strings.Cut(entry.Value, ":")
+		entry.Value = source + ":" + rest
+	case yaml.MappingNode:
+		mappingValue(entry, "source").Value = source
+		if t := mappingValue(entry, "type"); t != nil {
+			t.Value = kind
+		}
+	}
+}
+
+// Returns the value of the key in a mapping node adding an empty mapping when the key is missing
+func ensureMapping(node *yaml.Node, key string) *yaml.Node {
+	if value := mappingValue(node, key); value != nil {
+		return value
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
+
+// Sets the key of a mapping node to a string scalar adding the key when it is missing
+func setMappingScalar(node *yaml.Node, key, value string) {
+	if existing := mappingValue(node, key); existing != nil {
+		existing.Kind, existing.Tag, existing.Value, existing.Content = yaml.ScalarNode, "!!str", value, nil
+		return
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
+
+// Returns the value of the key in a mapping node turning a missing or empty value into a mapping
+func ensureMappingValue(node *yaml.Node, key string) *yaml.Node {
+	value := mappingValue(node, key)
+	if value == nil {
+		return ensureMapping(node, key)
+	}
+	if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+		value.Kind, value.Tag, value.Value = yaml.MappingNode, "!!map", ""
+	}
+	return value
+}
+
+// Appends the string to a sequence node unless it already holds it
+func appendUniqueScalar(seq *yaml.Node, value string) {
+	for _, entry := range seq.Content {
+		if entry.Value == value {
+			return
+		}
+	}
+	seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+// Reports whether any of the services mounts the named volume
+func isVolumeReferenced(services *yaml.Node, volume string) bool {
+	for _, name := range mappingKeys(services) {
+		volumes := mappingValue(mappingValue(services, name), "volumes")
+		if volumes == nil {
+			continue
+		}
+		for _, entry := range volumes.Content {
+			if volumeSource(entry) == volume {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+// Package templates embeds the docker compose and .env templates so that each release of the cli
+// bootstraps projects from the templates it was built with, even when offline.
+//
+// mongo.env and postgres.env are not embedded, they stay in the repository for the released versions that
+// still download them before conduit.env replaced both.
+package templates
+
+import "embed"
+
+//go:embed docker-compose.yml conduit.env
+var FS embed.FS